@@ -11,4 +11,7 @@ require (
 	github.com/gabe-lee/gengeom v0.1.2
 	github.com/gabe-lee/genmath v1.3.5
 	github.com/gabe-lee/genutils v1.0.4
+	golang.org/x/image v0.5.0
 )
+
+require golang.org/x/text v0.7.0 // indirect
@@ -12,4 +12,19 @@ type App struct {
 	File       FileProvider
 	Audio      AudioProvider
 	Clipboard  ClipboardProvider
+	Time       TimeProvider
+	quit       bool
+	loader     *LoaderPool
+	// Set by EnableGamepadCursor
+	gamepadCursor *GamepadCursor
+}
+
+// Saves a screenshot of the surface as a PNG through the app's File provider
+func (a *App) SaveSurfacePNG(surfaceID SurfaceID, fileName string) DeepError {
+	return a.Graphics.SaveSurfacePNG(surfaceID, a.File, fileName)
+}
+
+// Makes loop helpers such as RunFixedStep return after the current frame
+func (a *App) Quit() {
+	a.quit = true
 }
@@ -0,0 +1,154 @@
+package polyapp
+
+import (
+	"fmt"
+
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// A cubic Bezier curve from Start to End, pulled towards Control1 and Control2.
+// Straight lines and quadratic curves are cubics too, see NewLineBezier and NewQuadraticBezier.
+type BezierSegment struct {
+	Start    Vec2
+	Control1 Vec2
+	Control2 Vec2
+	End      Vec2
+}
+
+func NewLineBezier(start Vec2, end Vec2) BezierSegment {
+	return BezierSegment{start, start, end, end}
+}
+
+// Creates the cubic segment tracing the same curve as the quadratic Bezier with a single control point
+func NewQuadraticBezier(start Vec2, control Vec2, end Vec2) BezierSegment {
+	return BezierSegment{
+		Start:    start,
+		Control1: start.Add(control.Sub(start).Scale(2.0 / 3.0)),
+		Control2: end.Add(control.Sub(end).Scale(2.0 / 3.0)),
+		End:      end,
+	}
+}
+
+// Returns the point at t (0 at Start, 1 at End)
+func (s BezierSegment) Point(t float32) Vec2 {
+	u := 1 - t
+	return s.Start.Scale(u * u * u).
+		Add(s.Control1.Scale(3 * u * u * t)).
+		Add(s.Control2.Scale(3 * u * t * t)).
+		Add(s.End.Scale(t * t * t))
+}
+
+// Splits the segment at t into the part before and the part after it
+func (s BezierSegment) Split(t float32) (BezierSegment, BezierSegment) {
+	ab := s.Start.LerpOther(s.Control1, float64(t))
+	bc := s.Control1.LerpOther(s.Control2, float64(t))
+	cd := s.Control2.LerpOther(s.End, float64(t))
+	abc := ab.LerpOther(bc, float64(t))
+	bcd := bc.LerpOther(cd, float64(t))
+	mid := abc.LerpOther(bcd, float64(t))
+	return BezierSegment{s.Start, ab, abc, mid}, BezierSegment{mid, bcd, cd, s.End}
+}
+
+// Appends points along the segment to out, excluding Start and ending with End, so that no part of the curve
+// is further than tolerance from the straight lines between them. Curves are halved until their control
+// points are within tolerance of the segment from start to end, down to at most 2^maxBezierDepth pieces.
+func (s BezierSegment) Flatten(tolerance float32, out []Vec2) []Vec2 {
+	return s.flatten(tolerance, 0, out)
+}
+
+// Returns points along the quadratic Bezier curve from start to end, starting with start and ending with end,
+// with no part of the curve further than tolerance from the lines between them (see BezierSegment.Flatten)
+func FlattenQuadBezier(start Vec2, control Vec2, end Vec2, tolerance float32) []Vec2 {
+	return NewQuadraticBezier(start, control, end).Flatten(tolerance, []Vec2{start})
+}
+
+// Same as FlattenQuadBezier for a cubic curve with two control points
+func FlattenCubicBezier(start Vec2, control1 Vec2, control2 Vec2, end Vec2, tolerance float32) []Vec2 {
+	return BezierSegment{start, control1, control2, end}.Flatten(tolerance, []Vec2{start})
+}
+
+const maxBezierDepth = 16
+
+func (s BezierSegment) flatten(tolerance float32, depth int, out []Vec2) []Vec2 {
+	if depth >= maxBezierDepth || s.flatEnough(tolerance) {
+		return append(out, s.End)
+	}
+	before, after := s.Split(0.5)
+	return after.flatten(tolerance, depth+1, before.flatten(tolerance, depth+1, out))
+}
+
+// The curve lies inside the hull of its 4 points, so when both control points are within tolerance of the
+// straight segment from Start to End, so is every point of the curve
+func (s BezierSegment) flatEnough(tolerance float32) bool {
+	chord := s.End.Sub(s.Start)
+	lengthSq := chord.Dot(chord)
+	for _, c := range [2]Vec2{s.Control1, s.Control2} {
+		t := float32(0)
+		if lengthSq > 0 {
+			t = math.Clamp(0, c.Sub(s.Start).Dot(chord)/lengthSq, 1)
+		}
+		if c.Sub(s.Start.Add(chord.Scale(t))).Len() > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// Splits segments into closed contours: a segment starting somewhere other than where the previous one ended
+// begins a new contour, and every contour joins its last point back to its first
+func bezierContours(segments []BezierSegment, tolerance float32) [][]Vec2 {
+	contours := make([][]Vec2, 0, 1)
+	var contour []Vec2
+	for i, s := range segments {
+		if i == 0 || !s.Start.Equals(segments[i-1].End) {
+			if len(contour) > 0 {
+				contours = append(contours, contour)
+			}
+			contour = []Vec2{s.Start}
+		}
+		contour = s.Flatten(tolerance, contour)
+	}
+	if len(contour) > 0 {
+		contours = append(contours, contour)
+	}
+	return contours
+}
+
+// Builds the geometry of AddBezierFill2D, with zero normals
+func BuildBezierFill2D(segments []BezierSegment, color ColorFA, flatnessTolerance float32) ([]Vertex, []uint32) {
+	points, indexes := TriangulatePolygon2D(bezierContours(segments, flatnessTolerance))
+	vertices := make([]Vertex, len(points))
+	for i, p := range points {
+		vertices[i] = Vertex{Pos: p.AsVec3(), Color: color}
+	}
+	return vertices, indexes
+}
+
+// Fills the region bounded by segments, flattening each curve to within flatnessTolerance (see
+// BezierSegment.Flatten) and triangulating the result with TriangulatePolygon2D. Segments form several
+// contours when one does not start where the previous ended, and overlapping contours are filled with the
+// even-odd rule, so a contour inside another cuts a hole (like the inside of an "o" glyph).
+// Vertices are untextured (UV {0, 0}).
+func (g GraphicsProvider) AddBezierFill2D(batchID BatchID, segments []BezierSegment, color ColorFA, flatnessTolerance float32) (BatchShape, DeepError) {
+	if flatnessTolerance <= 0 {
+		return BatchShape{}, utils.NewDeepError("[PolyApp] AddBezierFill2D(): flatness tolerance must be greater than 0")
+	}
+	dErr := utils.NewDeepError("[PolyApp] AddBezierFill2D():")
+	dErr.IsErr = false
+	vertices, indexes := BuildBezierFill2D(segments, color, flatnessTolerance)
+	if len(indexes) == 0 {
+		dErr.AddChildError(fmt.Errorf("segments enclose no area"))
+		return BatchShape{}, dErr
+	}
+	for i := range vertices {
+		vertices[i].Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	}
+	bSlice, err := g.AddShapeWithVertices(batchID, ShapePrototype{
+		VertCount:  uint32(len(vertices)),
+		IndexCount: uint32(len(indexes)),
+		Indexes:    indexes,
+	}, vertices)
+	dErr.AddChildDeepError(err)
+	return bSlice, dErr
+}
@@ -0,0 +1,82 @@
+package polyapp
+
+import (
+	"testing"
+
+	math "github.com/gabe-lee/genmath"
+)
+
+// Returns the distance from p to the nearest of the lines between consecutive points
+func polylineDistance(points []Vec2, p Vec2) float32 {
+	best := float32(-1)
+	for i := 1; i < len(points); i += 1 {
+		a, chord := points[i-1], points[i].Sub(points[i-1])
+		t := float32(0)
+		if lengthSq := chord.Dot(chord); lengthSq > 0 {
+			t = math.Clamp(0, p.Sub(a).Dot(chord)/lengthSq, 1)
+		}
+		if d := p.Sub(a.Add(chord.Scale(t))).Len(); best < 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func TestFlattenBezierDeviation(t *testing.T) {
+	curves := map[string]BezierSegment{
+		"quadratic": NewQuadraticBezier(Vec2{0, 0}, Vec2{50, 100}, Vec2{100, 0}),
+		"s curve":   {Vec2{0, 0}, Vec2{100, 0}, Vec2{0, 100}, Vec2{100, 100}},
+		"loop":      {Vec2{0, 0}, Vec2{150, 100}, Vec2{-50, 100}, Vec2{100, 0}},
+		// control points beyond the end, so the curve doubles back along its chord
+		"doubling back": {Vec2{0, 0}, Vec2{200, 0}, Vec2{200, 0}, Vec2{100, 0}},
+	}
+	for name, curve := range curves {
+		prevCount := 0
+		for _, tolerance := range []float32{4, 1, 0.25, 0.01} {
+			points := FlattenCubicBezier(curve.Start, curve.Control1, curve.Control2, curve.End, tolerance)
+			if points[0] != curve.Start || points[len(points)-1] != curve.End {
+				t.Errorf("%s tolerance %v: points run from %v to %v, want %v to %v", name, tolerance, points[0], points[len(points)-1], curve.Start, curve.End)
+			}
+			maxDeviation := float32(0)
+			for i := 0; i <= 2000; i += 1 {
+				maxDeviation = math.Max(maxDeviation, polylineDistance(points, curve.Point(float32(i)/2000)))
+			}
+			// allow for float32 rounding in the curve evaluation
+			if maxDeviation > tolerance+1e-3 {
+				t.Errorf("%s tolerance %v: curve is %v from the flattened lines", name, tolerance, maxDeviation)
+			}
+			if len(points) < prevCount {
+				t.Errorf("%s tolerance %v: %d points, fewer than the %d of a looser tolerance", name, tolerance, len(points), prevCount)
+			}
+			prevCount = len(points)
+		}
+	}
+}
+
+func TestFlattenQuadBezierPointsOnCurve(t *testing.T) {
+	start, control, end := Vec2{0, 0}, Vec2{30, 80}, Vec2{60, 10}
+	points := FlattenQuadBezier(start, control, end, 0.5)
+	if len(points) < 3 {
+		t.Fatalf("curved quadratic flattened to %d points", len(points))
+	}
+	curve := NewQuadraticBezier(start, control, end)
+	for i, p := range points {
+		nearest := float32(-1)
+		for j := 0; j <= 2000; j += 1 {
+			if d := p.Sub(curve.Point(float32(j) / 2000)).Len(); nearest < 0 || d < nearest {
+				nearest = d
+			}
+		}
+		// the dense samples are less than 0.1 apart along this curve
+		if nearest > 0.1 {
+			t.Errorf("point %d at %v is %v from the curve", i, p, nearest)
+		}
+	}
+}
+
+func TestFlattenStraightLine(t *testing.T) {
+	points := FlattenQuadBezier(Vec2{0, 0}, Vec2{5, 5}, Vec2{10, 10}, 0.1)
+	if len(points) != 2 {
+		t.Errorf("straight quadratic flattened to %d points, want 2", len(points))
+	}
+}
@@ -0,0 +1,194 @@
+package polyapp
+
+import (
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Describes the view of a renderer.
+//
+// Mode uses the camera bits of VertexFlags:
+//   - NoCam: the view-projection is the identity matrix, so vertex positions are passed
+//     through untransformed and used directly as clip space coordinates spanning the
+//     draw surface (the zero value Camera)
+//   - Cam2D: uses Center, Zoom and Rotation
+//   - Cam3D: uses Position, Target, Up, FOV, Near and Far
+//
+// Convention is set per renderer with SetRendererCoordinateConvention and kept when the camera changes.
+type Camera struct {
+	Mode       VertexFlags
+	Convention CoordinateConvention
+	Center     Vec2
+	Zoom       float32
+	Rotation   float32
+	Position   Vec3
+	Target     Vec3
+	Up         Vec3
+	FOV        float32
+	Near       float32
+	Far        float32
+}
+
+// Chooses where the origin of 2D positions is and which way +Y points on the draw surface
+type CoordinateConvention uint8
+
+const (
+	// Clip space: with no camera positions span -1 to 1 across the surface whatever its size, and a Cam2D
+	// shows Center in the middle of the surface with +Y following XRightYUpZAway() (the default)
+	YUpOriginCenter CoordinateConvention = iota
+	// Surface pixels: with no camera {0, 0} is the top-left corner of the surface and {width, height} the
+	// bottom-right, and a Cam2D shows Center at the top-left corner (a scroll offset), scaled by Zoom and
+	// rotated around that corner. +Y always runs down the surface, so Rect2D and text coordinates match
+	// mouse positions and image pixels. The projection is rebuilt from the surface size on every draw,
+	// so resizing a surface shows more or less of the world instead of stretching it.
+	YDownOriginTopLeft
+)
+
+func NewCamera2D(center Vec2, zoom float32, rotationDeg float32) Camera {
+	return Camera{
+		Mode:     Cam2D,
+		Center:   center,
+		Zoom:     zoom,
+		Rotation: rotationDeg,
+	}
+}
+
+func NewCamera3D(position Vec3, target Vec3, up Vec3, fovDeg float32, near float32, far float32) Camera {
+	return Camera{
+		Mode:     Cam3D,
+		Position: position,
+		Target:   target,
+		Up:       up,
+		FOV:      fovDeg,
+		Near:     near,
+		Far:      far,
+	}
+}
+
+// Returns the matrix transforming world positions into clip space (-1 to 1 on every axis, +Y up)
+// for a draw surface of the given size, where axes is the result of XRightYUpZAway().
+// Cam3D ignores Convention.
+func (c Camera) ViewProjection(surfaceSize Vec2, axes Vec3) Mat4 {
+	switch c.Mode & CamMask {
+	case Cam2D:
+		return c.viewProjection2D(surfaceSize, axes)
+	case Cam3D:
+		return c.viewProjection3D(surfaceSize, axes)
+	default:
+		if c.Convention == YDownOriginTopLeft {
+			return Camera{Convention: YDownOriginTopLeft, Zoom: 1}.viewProjection2D(surfaceSize, axes)
+		}
+		return IdentityMat4
+	}
+}
+
+func (c Camera) viewProjection2D(surfaceSize Vec2, axes Vec3) Mat4 {
+	cos, sin := math.CosDeg(c.Rotation), math.SinDeg(c.Rotation)
+	kx := 2 * c.Zoom / surfaceSize.X()
+	ky := 2 * c.Zoom / surfaceSize.Y() * math.Sign(axes.Y())
+	// offset of the origin from the middle of the surface in clip space
+	var ox, oy float32
+	if c.Convention == YDownOriginTopLeft {
+		ky = -2 * c.Zoom / surfaceSize.Y()
+		ox, oy = -1, 1
+	}
+	tx := -(cos*c.Center.X() + sin*c.Center.Y())
+	ty := -(-sin*c.Center.X() + cos*c.Center.Y())
+	return Mat4{
+		kx * cos, ky * -sin, 0, 0,
+		kx * sin, ky * cos, 0, 0,
+		0, 0, 1, 0,
+		kx*tx + ox, ky*ty + oy, 0, 1,
+	}
+}
+
+func (c Camera) viewProjection3D(surfaceSize Vec2, axes Vec3) Mat4 {
+	away := math.Sign(axes.Z())
+	f := c.Target.Sub(c.Position).Norm()
+	r := f.Cross(c.Up).Norm().Scale(-away)
+	u := r.Cross(f).Scale(-away)
+	sy := 1 / math.TanDeg(c.FOV/2)
+	sx := sy / (surfaceSize.X() / surfaceSize.Y())
+	a := (c.Far + c.Near) / (c.Far - c.Near)
+	b := -2 * c.Far * c.Near / (c.Far - c.Near)
+	rp, up, fp := -r.Dot(c.Position), -u.Dot(c.Position), -f.Dot(c.Position)
+	return Mat4{
+		sx * r.X(), sy * u.X(), a * f.X(), f.X(),
+		sx * r.Y(), sy * u.Y(), a * f.Y(), f.Y(),
+		sx * r.Z(), sy * u.Z(), a * f.Z(), f.Z(),
+		sx * rp, sy * up, a*fp + b, fp,
+	}
+}
+
+// Converts a world position into pixel coordinates on the surface (origin top-left, +Y down)
+// using the renderer's current camera
+func (g GraphicsProvider) WorldToScreen(rendererID RendererID, surfaceID SurfaceID, world Vec3) (Vec2, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] WorldToScreen():")
+	dErr.IsErr = false
+	viewProj, viewport, err := g.cameraViewProjection(rendererID, surfaceID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return ZeroVec2, dErr
+	}
+	clip := viewProj.Transform(Vec4{world.X(), world.Y(), world.Z(), 1})
+	if clip.W() <= 0 {
+		return ZeroVec2, utils.NewDeepError("[PolyApp] WorldToScreen(): world position is behind the camera")
+	}
+	ndc := Vec2{clip.X() / clip.W(), clip.Y() / clip.W()}
+	return Vec2{viewport[0].X() + (ndc.X()+1)/2*viewport.W(), viewport[0].Y() + (1-ndc.Y())/2*viewport.H()}, dErr
+}
+
+// Converts pixel coordinates on the surface (origin top-left, +Y down) into a world position
+// using the renderer's current camera.
+//
+// For Cam3D, depth is the distance in front of the camera along its view direction,
+// otherwise depth is used as the clip space Z.
+func (g GraphicsProvider) ScreenToWorld(rendererID RendererID, surfaceID SurfaceID, screen Vec2, depth float32) (Vec3, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] ScreenToWorld():")
+	dErr.IsErr = false
+	camera, err := g.GetCamera(rendererID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return ZeroVec3, dErr
+	}
+	viewProj, viewport, err := g.cameraViewProjection(rendererID, surfaceID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return ZeroVec3, dErr
+	}
+	inverse, ok := viewProj.Inverse()
+	if !ok {
+		return ZeroVec3, utils.NewDeepError("[PolyApp] ScreenToWorld(): camera view-projection cannot be inverted")
+	}
+	ndcZ := depth
+	if camera.Mode&CamMask == Cam3D {
+		if depth <= 0 {
+			return ZeroVec3, utils.NewDeepError("[PolyApp] ScreenToWorld(): depth is behind the camera")
+		}
+		ndcZ = (camera.Far+camera.Near)/(camera.Far-camera.Near) - 2*camera.Far*camera.Near/((camera.Far-camera.Near)*depth)
+	}
+	local := screen.Sub(viewport[0])
+	ndc := Vec4{local.X()/viewport.W()*2 - 1, 1 - local.Y()/viewport.H()*2, ndcZ, 1}
+	world := inverse.Transform(ndc)
+	if world.W() == 0 {
+		return ZeroVec3, utils.NewDeepError("[PolyApp] ScreenToWorld(): screen position does not project into the world")
+	}
+	return Vec3{world.X() / world.W(), world.Y() / world.W(), world.Z() / world.W()}, dErr
+}
+
+// Returns the camera's view-projection and the surface's viewport in pixels
+func (g GraphicsProvider) cameraViewProjection(rendererID RendererID, surfaceID SurfaceID) (Mat4, Rect2D, DeepError) {
+	camera, err := g.GetCamera(rendererID)
+	if err.IsErr {
+		return IdentityMat4, Rect2D{}, err
+	}
+	iViewport, err := g.GetViewport(surfaceID)
+	if err.IsErr {
+		return IdentityMat4, Rect2D{}, err
+	}
+	viewport := Rect2D{
+		{float32(iViewport[0].X()), float32(iViewport[0].Y())},
+		{float32(iViewport[1].X()), float32(iViewport[1].Y())},
+	}
+	return camera.ViewProjection(Vec2{viewport.W(), viewport.H()}, g.XRightYUpZAway()), viewport, err
+}
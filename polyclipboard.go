@@ -1,12 +1,110 @@
 package polyapp
 
+import "sync"
+
+// Well-known clipboard formats, backends translate these to and from the platform's own text and image formats
+const (
+	MimeText     = "text/plain;charset=utf-8"
+	MimeImagePNG = "image/png"
+)
+
 type ClipboardInterface interface {
 	SetClipboardText(text string)
-	GetClipboardText() string
+	// Returns the clipboard's text. An empty clipboard, or one holding no text, returns "" with a nil error;
+	// an error means the clipboard could not be read at all (no access, unsupported platform or no display).
+	GetClipboardText() (string, error)
+	// Replaces the clipboard contents with data in the given format.
+	//
+	// Formats other than the well-known Mime* constants are custom formats. Platforms without custom
+	// clipboard formats (such as the web) return an error for them, and on most platforms custom data
+	// can only be read back by applications that ask for the same mimeType.
+	SetClipboardData(mimeType string, data []byte) error
+	// Returns the clipboard contents in the given format, or an error if the clipboard has no data in that format
+	GetClipboardData(mimeType string) ([]byte, error)
+	// Returns the formats the clipboard currently holds data in, an empty clipboard returns none with a nil error
+	GetClipboardFormats() ([]string, error)
+	// Calls op whenever the clipboard contents change, including changes made through this interface
+	SetCallbackOnClipboardChange(op func())
 }
 
 var _ ClipboardInterface = (*ClipboardProvider)(nil)
 
 type ClipboardProvider struct {
 	ClipboardInterface
+	// Set by EnableClipboardHistory
+	history *ClipboardHistory
+}
+
+// Starts recording the clipboard's text every time it changes, keeping the latest max entries.
+// This takes over SetCallbackOnClipboardChange, a callback set afterwards should call
+// GetClipboardHistoryRecorder().Capture() itself to keep the history recording.
+func (c *ClipboardProvider) EnableClipboardHistory(max uint32) {
+	history := NewClipboardHistory(max)
+	c.history = history
+	clipboard := c.ClipboardInterface
+	c.SetCallbackOnClipboardChange(func() {
+		history.Capture(clipboard)
+	})
+}
+
+// Returns the recorded clipboard texts, newest first, or nil if EnableClipboardHistory has not been called
+func (c ClipboardProvider) GetClipboardHistory() []string {
+	if c.history == nil {
+		return nil
+	}
+	return c.history.Entries()
+}
+
+// Returns the history EnableClipboardHistory records into, or nil if it has not been called
+func (c ClipboardProvider) GetClipboardHistoryRecorder() *ClipboardHistory {
+	return c.history
+}
+
+// A ring buffer of the most recent clipboard texts, safe to use from multiple goroutines
+type ClipboardHistory struct {
+	lock    sync.Mutex
+	entries []string
+	next    int
+	count   int
+}
+
+func NewClipboardHistory(max uint32) *ClipboardHistory {
+	return &ClipboardHistory{entries: make([]string, max)}
+}
+
+// Adds text as the newest entry, dropping the oldest when full. Empty text and text equal to the
+// newest entry are ignored.
+func (h *ClipboardHistory) Add(text string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if text == "" || len(h.entries) == 0 {
+		return
+	}
+	if h.count > 0 && h.entries[(h.next+len(h.entries)-1)%len(h.entries)] == text {
+		return
+	}
+	h.entries[h.next] = text
+	h.next = (h.next + 1) % len(h.entries)
+	if h.count < len(h.entries) {
+		h.count += 1
+	}
+}
+
+// Reads the clipboard's text and adds it, ignoring clipboards that cannot be read
+func (h *ClipboardHistory) Capture(clipboard ClipboardInterface) {
+	text, err := clipboard.GetClipboardText()
+	if err == nil {
+		h.Add(text)
+	}
+}
+
+// Returns the entries newest first
+func (h *ClipboardHistory) Entries() []string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	entries := make([]string, h.count)
+	for i := range entries {
+		entries[i] = h.entries[(h.next+len(h.entries)-1-i)%len(h.entries)]
+	}
+	return entries
 }
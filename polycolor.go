@@ -0,0 +1,163 @@
+package polyapp
+
+import (
+	"fmt"
+
+	color "github.com/gabe-lee/color"
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Converts any of the color types to ColorFA, returning false if src is not a color.
+// Formats without alpha (ColorF, Color24, Color48) convert with an alpha of 1.
+func ColorToFA(src any) (ColorFA, bool) {
+	switch c := src.(type) {
+	case ColorFA:
+		return c, true
+	case ColorF:
+		return c.ToColorFA(), true
+	case Color64:
+		return c.ToColorFA(), true
+	case Color48:
+		return c.ToColorFA(), true
+	case Color32:
+		return c.ToColorFA(), true
+	case Color24:
+		return c.ToColorFA(), true
+	case Color16:
+		return c.ToColorFA(), true
+	case Color8:
+		return c.ToColorFA(), true
+	default:
+		return ColorFA{}, false
+	}
+}
+
+// Converts any of the color types to the color type stored by a batch with targetFlags, rounding each
+// channel to the nearest value the target can hold. The largest error per channel is:
+//   - ColFA, ColF: none (ColF drops alpha)
+//   - Col64, Col48: 1/131070 (Col48 drops alpha)
+//   - Col32, Col24: 1/510 (Col24 drops alpha)
+//   - Col16: 1/30, visible as banding in gradients (see DitherColor16)
+//   - Col8: 1/6, visible as banding in most gradients (see DitherColor8)
+func ConvertColor(src any, targetFlags VertexFlags) (any, DeepError) {
+	c, ok := ColorToFA(src)
+	if !ok {
+		return nil, utils.NewDeepError(fmt.Sprintf("[PolyApp] ConvertColor(): %T is not a color type", src))
+	}
+	dErr := utils.NewDeepError("[PolyApp] ConvertColor():")
+	dErr.IsErr = false
+	switch targetFlags & ColMask {
+	case ColFA:
+		return c, dErr
+	case ColF:
+		return c.ToColorF(), dErr
+	case Col64:
+		return c.ToColor64(), dErr
+	case Col48:
+		return c.ToColor48(), dErr
+	case Col32:
+		return c.ToColor32(), dErr
+	case Col24:
+		return c.ToColor24(), dErr
+	case Col16:
+		return c.ToColor16(), dErr
+	case Col8:
+		return c.ToColor8(), dErr
+	default:
+		return nil, utils.NewDeepError("[PolyApp] ConvertColor(): target flags do not store a color")
+	}
+}
+
+// 4x4 ordered dithering thresholds, centered on 0
+var bayer4x4 = [16]float32{
+	0, 8, 2, 10,
+	12, 4, 14, 6,
+	3, 11, 1, 9,
+	15, 7, 13, 5,
+}
+
+// Offsets c by the ordered dithering threshold of pixel (x, y), scaled for a format with the given
+// number of levels per channel, so that quantizing it spreads the rounding error over a 4x4 pattern
+func ditherColor(c ColorFA, x int, y int, levels float32) ColorFA {
+	threshold := (bayer4x4[(y&3)*4+(x&3)]+0.5)/16 - 0.5
+	offset := threshold / (levels - 1)
+	for i := range c {
+		c[i] = math.Clamp(0, c[i]+offset, 1)
+	}
+	return c
+}
+
+// Same as c.ToColor16(), but with ordered dithering for the pixel at (x, y) so gradients don't band
+func DitherColor16(c ColorFA, x int, y int) Color16 {
+	return ditherColor(c, x, y, 16).ToColor16()
+}
+
+// Same as c.ToColor8(), but with ordered dithering for the pixel at (x, y) so gradients don't band
+func DitherColor8(c ColorFA, x int, y int) Color8 {
+	return ditherColor(c, x, y, 4).ToColor8()
+}
+
+// Colors in HSV and HSL take hue in degrees (0-360) and every other component in the range 0-1.
+// Lighten, Darken, SetAlpha and BlendWithAlpha (lerp) are already methods of ColorFA, the color package
+// types are aliased so the functions below cover what it lacks.
+
+// Same as NewColorHSVA
+func ColorFromHSV(h float32, s float32, v float32, a float32) ColorFA {
+	return color.NewColorHSVA(h, s, v, a)
+}
+
+// Creates a color from hue, saturation and lightness, where lightness 1 is always white
+func ColorFromHSL(h float32, s float32, l float32, a float32) ColorFA {
+	s, l = math.Clamp(0, s, 1), math.Clamp(0, l, 1)
+	v := l + s*math.Min(l, 1-l)
+	sv := float32(0)
+	if v > 0 {
+		sv = 2 * (1 - l/v)
+	}
+	return color.NewColorHSVA(h, sv, v, a)
+}
+
+// Returns the hue, saturation, lightness and alpha of c, the inverse of ColorFromHSL
+func ColorHSLA(c ColorFA) (h float32, s float32, l float32, a float32) {
+	h, sv, v, a := c.HSVA()
+	l = v * (1 - sv/2)
+	if l > 0 && l < 1 {
+		s = (v - l) / math.Min(l, 1-l)
+	}
+	return h, s, l, a
+}
+
+// Adds amount to the HSV saturation of c, a negative amount desaturates towards gray
+func SaturateColor(c ColorFA, amount float32) ColorFA {
+	return c.SetSat(math.Clamp(0, c.Sat()+amount, 1))
+}
+
+// Color values passed to polyapp (vertex colors, tints, clear colors and texture pixels) are sRGB encoded, the
+// same values an image editor or color picker shows. Blending them directly is only correct for linear values,
+// so renderers with SetRendererSRGB enabled convert to linear before interpolating and blending and back to
+// sRGB when writing to the surface. Alpha is always linear.
+
+// Converts the color channels of an sRGB encoded color to linear light, leaving alpha untouched
+func SRGBToLinear(c ColorFA) ColorFA {
+	for i := 0; i < 3; i += 1 {
+		if c[i] <= 0.04045 {
+			c[i] = c[i] / 12.92
+		} else {
+			c[i] = math.Pow((c[i]+0.055)/1.055, 2.4)
+		}
+	}
+	return c
+}
+
+// Converts the color channels of a linear light color to sRGB encoding, leaving alpha untouched
+func LinearToSRGB(c ColorFA) ColorFA {
+	for i := 0; i < 3; i += 1 {
+		if c[i] <= 0.0031308 {
+			c[i] = c[i] * 12.92
+		} else {
+			c[i] = 1.055*math.Pow(c[i], 1/2.4) - 0.055
+		}
+	}
+	return c
+}
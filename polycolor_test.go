@@ -0,0 +1,150 @@
+package polyapp
+
+import (
+	gomath "math"
+	"testing"
+)
+
+func TestConvertColorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    VertexFlags
+		maxError float64
+		hasAlpha bool
+	}{
+		{"ColFA", ColFA, 0, true},
+		{"ColF", ColF, 0, false},
+		{"Col64", Col64, 1.0 / 131070, true},
+		{"Col48", Col48, 1.0 / 131070, false},
+		{"Col32", Col32, 1.0 / 510, true},
+		{"Col24", Col24, 1.0 / 510, false},
+		{"Col16", Col16, 1.0 / 30, true},
+		{"Col8", Col8, 1.0 / 6, true},
+	}
+	for _, tt := range tests {
+		for i := 0; i <= 100; i += 1 {
+			v := float32(i) / 100
+			src := ColorFA{v, 1 - v, v * v, 1 - v*v}
+			converted, err := ConvertColor(src, tt.flags)
+			mustOk(t, err)
+			got, ok := ColorToFA(converted)
+			if !ok {
+				t.Fatalf("%s: ColorToFA(%T) reported it is not a color", tt.name, converted)
+			}
+			want := src
+			if !tt.hasAlpha {
+				want[3] = 1
+			}
+			for c := range want {
+				// allow for float32 rounding on top of the quantization error
+				if diff := gomath.Abs(float64(got[c] - want[c])); diff > tt.maxError+1e-6 {
+					t.Errorf("%s: channel %d of %v round tripped to %v, off by %v (max %v)", tt.name, c, src, got[c], diff, tt.maxError)
+				}
+			}
+		}
+	}
+}
+
+func TestConvertColorExactValues(t *testing.T) {
+	src := Color32(0x003380ff)
+	for _, flags := range []VertexFlags{ColFA, Col64, Col32} {
+		converted, err := ConvertColor(src, flags)
+		mustOk(t, err)
+		back, err := ConvertColor(converted, Col32)
+		mustOk(t, err)
+		if back != src {
+			t.Errorf("%T round tripped %v to %v", converted, src, back)
+		}
+	}
+}
+
+func TestConvertColorErrors(t *testing.T) {
+	if _, err := ConvertColor("red", Col32); !err.IsErr {
+		t.Error("converting a string returned no error")
+	}
+	if _, err := ConvertColor(testRed, Pos2D); !err.IsErr {
+		t.Error("converting to flags without a color returned no error")
+	}
+}
+
+func TestDitherColorAveragesToInput(t *testing.T) {
+	for _, v := range []float32{0.1, 0.3, 0.45, 0.8} {
+		c := ColorFA{v, v, v, 1}
+		var sum16, sum8 float64
+		for y := 0; y < 4; y += 1 {
+			for x := 0; x < 4; x += 1 {
+				d16, _ := ColorToFA(DitherColor16(c, x, y))
+				d8, _ := ColorToFA(DitherColor8(c, x, y))
+				sum16 += float64(d16[0])
+				sum8 += float64(d8[0])
+			}
+		}
+		// a 4x4 pattern averages to within a sixteenth of a quantization step
+		if diff := gomath.Abs(sum16/16 - float64(v)); diff > 1.0/15/16+1e-6 {
+			t.Errorf("Color16 dither of %v averages %v", v, sum16/16)
+		}
+		if diff := gomath.Abs(sum8/16 - float64(v)); diff > 1.0/3/16+1e-6 {
+			t.Errorf("Color8 dither of %v averages %v", v, sum8/16)
+		}
+	}
+}
+
+func colorNear(a ColorFA, b ColorFA) bool {
+	return near(a[0], b[0]) && near(a[1], b[1]) && near(a[2], b[2]) && near(a[3], b[3])
+}
+
+func TestColorFromHSVAndHSLAnchors(t *testing.T) {
+	tests := []struct {
+		hue  float32
+		want ColorFA
+	}{
+		{0, ColorFA{1, 0, 0, 1}},
+		{60, ColorFA{1, 1, 0, 1}},
+		{120, ColorFA{0, 1, 0, 1}},
+		{180, ColorFA{0, 1, 1, 1}},
+		{240, ColorFA{0, 0, 1, 1}},
+		{300, ColorFA{1, 0, 1, 1}},
+	}
+	for _, tt := range tests {
+		if got := ColorFromHSV(tt.hue, 1, 1, 1); !colorNear(got, tt.want) {
+			t.Errorf("ColorFromHSV(%v, 1, 1, 1) = %v, want %v", tt.hue, got, tt.want)
+		}
+		if got := ColorFromHSL(tt.hue, 1, 0.5, 1); !colorNear(got, tt.want) {
+			t.Errorf("ColorFromHSL(%v, 1, 0.5, 1) = %v, want %v", tt.hue, got, tt.want)
+		}
+	}
+	for _, hue := range []float32{0, 120, 240} {
+		if got := ColorFromHSL(hue, 1, 1, 1); !colorNear(got, ColorFA{1, 1, 1, 1}) {
+			t.Errorf("ColorFromHSL(%v, 1, 1, 1) = %v, want white", hue, got)
+		}
+		if got := ColorFromHSL(hue, 1, 0, 1); !colorNear(got, ColorFA{0, 0, 0, 1}) {
+			t.Errorf("ColorFromHSL(%v, 1, 0, 1) = %v, want black", hue, got)
+		}
+		if got := ColorFromHSL(hue, 0, 0.5, 0.5); !colorNear(got, ColorFA{0.5, 0.5, 0.5, 0.5}) {
+			t.Errorf("ColorFromHSL(%v, 0, 0.5, 0.5) = %v, want half gray", hue, got)
+		}
+	}
+}
+
+func TestColorHSLARoundTrip(t *testing.T) {
+	for _, hue := range []float32{0, 30, 200, 330} {
+		for _, s := range []float32{0.25, 1} {
+			for _, l := range []float32{0.2, 0.5, 0.75} {
+				gotH, gotS, gotL, gotA := ColorHSLA(ColorFromHSL(hue, s, l, 0.5))
+				if !near(gotH, hue) || !near(gotS, s) || !near(gotL, l) || gotA != 0.5 {
+					t.Errorf("ColorHSLA(ColorFromHSL(%v, %v, %v, 0.5)) = %v %v %v %v", hue, s, l, gotH, gotS, gotL, gotA)
+				}
+			}
+		}
+	}
+}
+
+func TestSaturateColor(t *testing.T) {
+	c := ColorFromHSV(0, 0.5, 1, 1)
+	if got := SaturateColor(c, 1); !colorNear(got, ColorFA{1, 0, 0, 1}) {
+		t.Errorf("fully saturated = %v, want red", got)
+	}
+	if got := SaturateColor(c, -1); !colorNear(got, ColorFA{1, 1, 1, 1}) {
+		t.Errorf("fully desaturated = %v, want white", got)
+	}
+}
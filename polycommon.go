@@ -16,6 +16,8 @@ type Vec2 = vecs.F32Vec2
 type IVec2 = vecs.I32Vec2
 type Vec3 = vecs.F32Vec3
 type IVec3 = vecs.I32Vec3
+type Vec4 = vecs.F32Vec4
+type IVec4 = vecs.I32Vec4
 type Rect2D = vecs.F32AABB2
 type IRect2D = vecs.I32AABB2
 type Rect3D = vecs.F32AABB3
@@ -29,6 +31,188 @@ type Line3D = vecs.F32Line3
 
 type VertExtra = [8]uint32
 
+// 3x3 float32 matrix stored in column-major order, used for 2D transforms
+type Mat3 [9]float32
+
+// 4x4 float32 matrix stored in column-major order, used for 3D transforms
+type Mat4 [16]float32
+
+// Matrix rotations match the genvecs Rotate methods: Rotate2D and Rotate3DZ turn X toward Y,
+// Rotate3DX turns Y toward Z and Rotate3DY turns Z toward X. For 2D this appears counter-clockwise
+// when XRightYUpZAway() has Y up and clockwise when it has Y down.
+
+var IdentityMat3 = Mat3{
+	1, 0, 0,
+	0, 1, 0,
+	0, 0, 1,
+}
+
+var IdentityMat4 = Mat4{
+	1, 0, 0, 0,
+	0, 1, 0, 0,
+	0, 0, 1, 0,
+	0, 0, 0, 1,
+}
+
+func Translate2D(offset Vec2) Mat3 {
+	return Mat3{
+		1, 0, 0,
+		0, 1, 0,
+		offset.X(), offset.Y(), 1,
+	}
+}
+
+func Rotate2D(degrees float32) Mat3 {
+	cos, sin := math.CosDeg(degrees), math.SinDeg(degrees)
+	return Mat3{
+		cos, sin, 0,
+		-sin, cos, 0,
+		0, 0, 1,
+	}
+}
+
+func Scale2D(scale Vec2) Mat3 {
+	return Mat3{
+		scale.X(), 0, 0,
+		0, scale.Y(), 0,
+		0, 0, 1,
+	}
+}
+
+// Returns m * other, which applies other first and m second
+func (m Mat3) Mul(other Mat3) (result Mat3) {
+	for col := 0; col < 3; col += 1 {
+		for row := 0; row < 3; row += 1 {
+			result[col*3+row] = m[row]*other[col*3] + m[3+row]*other[col*3+1] + m[6+row]*other[col*3+2]
+		}
+	}
+	return result
+}
+
+func (m Mat3) Transform(v Vec3) (result Vec3) {
+	for row := 0; row < 3; row += 1 {
+		result[row] = m[row]*v[0] + m[3+row]*v[1] + m[6+row]*v[2]
+	}
+	return result
+}
+
+func (m Mat3) TransformPoint(point Vec2) Vec2 {
+	return m.Transform(Vec3{point.X(), point.Y(), 1}).AsVec2()
+}
+
+// Returns the 2D transform as a Mat4 acting on X and Y, leaving Z untouched
+func (m Mat3) ToMat4() Mat4 {
+	return Mat4{
+		m[0], m[1], 0, m[2],
+		m[3], m[4], 0, m[5],
+		0, 0, 1, 0,
+		m[6], m[7], 0, m[8],
+	}
+}
+
+func Translate3D(offset Vec3) Mat4 {
+	return Mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		offset.X(), offset.Y(), offset.Z(), 1,
+	}
+}
+
+func Rotate3DX(degrees float32) Mat4 {
+	cos, sin := math.CosDeg(degrees), math.SinDeg(degrees)
+	return Mat4{
+		1, 0, 0, 0,
+		0, cos, sin, 0,
+		0, -sin, cos, 0,
+		0, 0, 0, 1,
+	}
+}
+
+func Rotate3DY(degrees float32) Mat4 {
+	cos, sin := math.CosDeg(degrees), math.SinDeg(degrees)
+	return Mat4{
+		cos, 0, -sin, 0,
+		0, 1, 0, 0,
+		sin, 0, cos, 0,
+		0, 0, 0, 1,
+	}
+}
+
+func Rotate3DZ(degrees float32) Mat4 {
+	cos, sin := math.CosDeg(degrees), math.SinDeg(degrees)
+	return Mat4{
+		cos, sin, 0, 0,
+		-sin, cos, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+func Scale3D(scale Vec3) Mat4 {
+	return Mat4{
+		scale.X(), 0, 0, 0,
+		0, scale.Y(), 0, 0,
+		0, 0, scale.Z(), 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Returns m * other, which applies other first and m second
+func (m Mat4) Mul(other Mat4) (result Mat4) {
+	for col := 0; col < 4; col += 1 {
+		for row := 0; row < 4; row += 1 {
+			result[col*4+row] = m[row]*other[col*4] + m[4+row]*other[col*4+1] + m[8+row]*other[col*4+2] + m[12+row]*other[col*4+3]
+		}
+	}
+	return result
+}
+
+func (m Mat4) TransformPoint(point Vec3) Vec3 {
+	result := m.Transform(Vec4{point.X(), point.Y(), point.Z(), 1})
+	if result.W() != 0 && result.W() != 1 {
+		return Vec3{result.X() / result.W(), result.Y() / result.W(), result.Z() / result.W()}
+	}
+	return Vec3{result.X(), result.Y(), result.Z()}
+}
+
+func (m Mat4) Transform(v Vec4) (result Vec4) {
+	for row := 0; row < 4; row += 1 {
+		result[row] = m[row]*v[0] + m[4+row]*v[1] + m[8+row]*v[2] + m[12+row]*v[3]
+	}
+	return result
+}
+
+// Returns the inverse of the matrix, or false if it is singular
+func (m Mat4) Inverse() (Mat4, bool) {
+	var inv Mat4
+	inv[0] = m[5]*m[10]*m[15] - m[5]*m[11]*m[14] - m[9]*m[6]*m[15] + m[9]*m[7]*m[14] + m[13]*m[6]*m[11] - m[13]*m[7]*m[10]
+	inv[4] = -m[4]*m[10]*m[15] + m[4]*m[11]*m[14] + m[8]*m[6]*m[15] - m[8]*m[7]*m[14] - m[12]*m[6]*m[11] + m[12]*m[7]*m[10]
+	inv[8] = m[4]*m[9]*m[15] - m[4]*m[11]*m[13] - m[8]*m[5]*m[15] + m[8]*m[7]*m[13] + m[12]*m[5]*m[11] - m[12]*m[7]*m[9]
+	inv[12] = -m[4]*m[9]*m[14] + m[4]*m[10]*m[13] + m[8]*m[5]*m[14] - m[8]*m[6]*m[13] - m[12]*m[5]*m[10] + m[12]*m[6]*m[9]
+	inv[1] = -m[1]*m[10]*m[15] + m[1]*m[11]*m[14] + m[9]*m[2]*m[15] - m[9]*m[3]*m[14] - m[13]*m[2]*m[11] + m[13]*m[3]*m[10]
+	inv[5] = m[0]*m[10]*m[15] - m[0]*m[11]*m[14] - m[8]*m[2]*m[15] + m[8]*m[3]*m[14] + m[12]*m[2]*m[11] - m[12]*m[3]*m[10]
+	inv[9] = -m[0]*m[9]*m[15] + m[0]*m[11]*m[13] + m[8]*m[1]*m[15] - m[8]*m[3]*m[13] - m[12]*m[1]*m[11] + m[12]*m[3]*m[9]
+	inv[13] = m[0]*m[9]*m[14] - m[0]*m[10]*m[13] - m[8]*m[1]*m[14] + m[8]*m[2]*m[13] + m[12]*m[1]*m[10] - m[12]*m[2]*m[9]
+	inv[2] = m[1]*m[6]*m[15] - m[1]*m[7]*m[14] - m[5]*m[2]*m[15] + m[5]*m[3]*m[14] + m[13]*m[2]*m[7] - m[13]*m[3]*m[6]
+	inv[6] = -m[0]*m[6]*m[15] + m[0]*m[7]*m[14] + m[4]*m[2]*m[15] - m[4]*m[3]*m[14] - m[12]*m[2]*m[7] + m[12]*m[3]*m[6]
+	inv[10] = m[0]*m[5]*m[15] - m[0]*m[7]*m[13] - m[4]*m[1]*m[15] + m[4]*m[3]*m[13] + m[12]*m[1]*m[7] - m[12]*m[3]*m[5]
+	inv[14] = -m[0]*m[5]*m[14] + m[0]*m[6]*m[13] + m[4]*m[1]*m[14] - m[4]*m[2]*m[13] - m[12]*m[1]*m[6] + m[12]*m[2]*m[5]
+	inv[3] = -m[1]*m[6]*m[11] + m[1]*m[7]*m[10] + m[5]*m[2]*m[11] - m[5]*m[3]*m[10] - m[9]*m[2]*m[7] + m[9]*m[3]*m[6]
+	inv[7] = m[0]*m[6]*m[11] - m[0]*m[7]*m[10] - m[4]*m[2]*m[11] + m[4]*m[3]*m[10] + m[8]*m[2]*m[7] - m[8]*m[3]*m[6]
+	inv[11] = -m[0]*m[5]*m[11] + m[0]*m[7]*m[9] + m[4]*m[1]*m[11] - m[4]*m[3]*m[9] - m[8]*m[1]*m[7] + m[8]*m[3]*m[5]
+	inv[15] = m[0]*m[5]*m[10] - m[0]*m[6]*m[9] - m[4]*m[1]*m[10] + m[4]*m[2]*m[9] + m[8]*m[1]*m[6] - m[8]*m[2]*m[5]
+	det := m[0]*inv[0] + m[1]*inv[4] + m[2]*inv[8] + m[3]*inv[12]
+	if det == 0 {
+		return m, false
+	}
+	det = 1 / det
+	for i := range inv {
+		inv[i] *= det
+	}
+	return inv, true
+}
+
 type ColorFA = color.ColorFA
 type ColorF = color.ColorF
 type Color64 = color.Color64
@@ -64,6 +248,18 @@ const (
 	ImgWEBP
 )
 
+// Returns the overlapping area of two rects, or false if they do not overlap
+func IntersectIRect2D(a IRect2D, b IRect2D) (IRect2D, bool) {
+	result := IRect2D{
+		IVec2{math.Max(a.Min().X(), b.Min().X()), math.Max(a.Min().Y(), b.Min().Y())},
+		IVec2{math.Min(a.Max().X(), b.Max().X()), math.Min(a.Max().Y(), b.Max().Y())},
+	}
+	if result.W() <= 0 || result.H() <= 0 {
+		return IRect2D{}, false
+	}
+	return result, true
+}
+
 type BufferZone struct {
 	Start uint32
 	End   uint32
@@ -79,6 +275,9 @@ type BufferZoneLL struct {
 }
 
 func (b *BufferZoneLL) Insert(zone BufferZone) {
+	if zone.Len() == 0 {
+		return
+	}
 	overlap, start, end := math.CombineRangesIfOverlap(b.Start, b.End, zone.Start, zone.End)
 	if overlap {
 		b.Start = start
@@ -98,6 +297,15 @@ func (b *BufferZoneLL) Insert(zone BufferZone) {
 	}
 }
 
+// Returns the length of the largest zone in the list
+func (b *BufferZoneLL) Largest() uint32 {
+	largest := uint32(0)
+	for zone := b; zone != nil; zone = zone.Next {
+		largest = math.Max(largest, zone.Len())
+	}
+	return largest
+}
+
 func (b *BufferZoneLL) Aquire(zoneSize uint32, last *BufferZoneLL) BufferZone {
 	if zoneSize <= b.Len() {
 		zone := BufferZone{Start: b.Start, End: b.Start + zoneSize}
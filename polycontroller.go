@@ -1,10 +1,170 @@
 package polyapp
 
+import math "github.com/gabe-lee/genmath"
+
 type ControllerInterface interface {
+	// Returns the stick's position as reported by the hardware, each axis from -1 to 1 with +Y down.
+	// Most sticks do not rest exactly at zero, see ControllerProvider.GetControllerStick.
+	GetControllerStickRaw(controllerID uint8, stick ControllerStick) Vec2
+	GetControllerButtonState(controllerID uint8, button ControllerButton) InputState
 }
 
 var _ ControllerInterface = (*ControllerProvider)(nil)
 
 type ControllerProvider struct {
 	ControllerInterface
+	// Radial deadzone GetControllerStick applies, 0 for none
+	StickDeadzone float32
+}
+
+type ControllerStick uint8
+
+const (
+	StickLeft ControllerStick = iota
+	StickRight
+)
+
+// Buttons named by their position, as the labels differ between controllers
+type ControllerButton uint8
+
+const (
+	ButtonSouth ControllerButton = iota // A on Xbox, Cross on PlayStation
+	ButtonEast
+	ButtonWest
+	ButtonNorth
+	ButtonLeftShoulder
+	ButtonRightShoulder
+	ButtonBack
+	ButtonStart
+	ButtonGuide
+	ButtonLeftStick
+	ButtonRightStick
+	ButtonDpadUp
+	ButtonDpadRight
+	ButtonDpadDown
+	ButtonDpadLeft
+)
+
+// Returns the stick's position with StickDeadzone applied by ApplyRadialDeadzone
+func (c ControllerProvider) GetControllerStick(controllerID uint8, stick ControllerStick) Vec2 {
+	return ApplyRadialDeadzone(c.GetControllerStickRaw(controllerID, stick), c.StickDeadzone)
+}
+
+// Returns 0 for values within deadzone of zero and rescales the rest so the output still covers the full
+// range, starting from 0 at the edge of the deadzone instead of jumping to it. For triggers (0 to 1) and
+// single axes (-1 to 1), the result is clamped to 1 in magnitude. deadzone is clamped to 0-1.
+func ApplyDeadzone(value float32, deadzone float32) float32 {
+	deadzone = math.Clamp(0, deadzone, 1)
+	magnitude := math.Abs(value)
+	if magnitude <= deadzone {
+		return 0
+	}
+	return math.Sign(value) * math.Min(1, (magnitude-deadzone)/(1-deadzone))
+}
+
+// Same as ApplyDeadzone for the distance of stick from its center, keeping its direction. Unlike applying
+// ApplyDeadzone to each axis, small movements along one axis are not snapped to the other axis, so
+// diagonal movement stays smooth. The result is at most 1 long.
+func ApplyRadialDeadzone(stick Vec2, deadzone float32) Vec2 {
+	length := stick.Len()
+	// a negative deadzone counts as 0, so a centered stick never divides by its zero length
+	if length <= math.Max(0, deadzone) {
+		return ZeroVec2
+	}
+	return stick.Scale(ApplyDeadzone(length, deadzone) / length)
+}
+
+// Stick deflection below which a GamepadCursor does not move, as a fraction of full deflection
+const DefaultGamepadCursorDeadzone = 0.2
+
+// Drives a cursor with a controller stick and clicks Mouse1 with a controller button, for apps used from
+// a couch or TV. App.EnableGamepadCursor sets one up that the app updates every frame, moving the real
+// cursor. Update can also be called directly with any stick and button to move a cursor the app draws itself.
+type GamepadCursor struct {
+	ControllerID uint8
+	// Window whose content area the cursor moves in, 0 by default
+	WindowID uint8
+	// Button that clicks Mouse1, ButtonSouth by default
+	Button ControllerButton
+	// Pixels per second at full stick deflection
+	Speed float32
+	// Deflection (0 to 1) ignored around the stick's center, defaults to DefaultGamepadCursorDeadzone.
+	// Movement starts from zero at the edge of the deadzone rather than jumping.
+	Deadzone float32
+	// Cursor position in the same pixels as GetMousePositionInWindow
+	Position Vec2
+	pressed  bool
+}
+
+func NewGamepadCursor(controllerID uint8, speed float32, start Vec2) *GamepadCursor {
+	return &GamepadCursor{ControllerID: controllerID, Button: ButtonSouth, Speed: speed, Deadzone: DefaultGamepadCursorDeadzone, Position: start}
+}
+
+// Moves the cursor by stick (each axis from -1 to 1, +Y down) over dt seconds, keeping it inside bounds, and
+// returns the new position. action is InputPressed or InputReleased on the frames the button changes state,
+// or InputUntouched.
+func (c *GamepadCursor) Update(stick Vec2, button bool, dt float32, bounds Rect2D) (pos Vec2, action InputAction) {
+	c.Position = c.Position.Add(ApplyRadialDeadzone(stick, c.Deadzone).Scale(c.Speed * dt))
+	c.Position = Vec2{math.Clamp(bounds[0].X(), c.Position.X(), bounds[1].X()), math.Clamp(bounds[0].Y(), c.Position.Y(), bounds[1].Y())}
+	action = InputUntouched
+	if button != c.pressed {
+		action = InputReleased
+		if button {
+			action = InputPressed
+		}
+		c.pressed = button
+	}
+	return c.Position, action
+}
+
+// Moves the cursor to where the real mouse moved it
+func (c *GamepadCursor) MouseMoved(pos Vec2) {
+	c.Position = pos
+}
+
+// Makes the left stick of the controller move the mouse cursor in window 0 at up to speed pixels per second,
+// and its ButtonSouth click Mouse1, from the next UpdateGamepadCursor on. Returns the cursor so its deadzone,
+// window and button can be changed. RunFixedStep updates it every frame, apps running their own loop call
+// UpdateGamepadCursor once per frame.
+//
+// The real mouse keeps working alongside it: the stick moves the cursor on from wherever the mouse last left
+// it, and mouse buttons are unaffected. The controller button's clicks arrive through the same callbacks as
+// the mouse's, so the app handles both the same way.
+func (a *App) EnableGamepadCursor(controllerID uint8, speed float32) *GamepadCursor {
+	pos, _ := a.Mouse.GetMousePositionInWindow(0)
+	a.gamepadCursor = NewGamepadCursor(controllerID, speed, pos)
+	return a.gamepadCursor
+}
+
+// Stops the stick and button set up by EnableGamepadCursor from driving the mouse
+func (a *App) DisableGamepadCursor() {
+	a.gamepadCursor = nil
+}
+
+// Moves the cursor set up by EnableGamepadCursor by its controller's left stick over dt seconds, keeping it
+// inside the window, and sends a Mouse1 press or release when its button changes. Does nothing when the
+// gamepad cursor is not enabled or the window's size cannot be read.
+func (a *App) UpdateGamepadCursor(dt float32) {
+	c := a.gamepadCursor
+	if c == nil {
+		return
+	}
+	size, err := a.Window.GetSize(c.WindowID)
+	if err != nil {
+		return
+	}
+	// the real mouse moved the cursor since the last update, more than backends rounding it to whole pixels would
+	if pos, _ := a.Mouse.GetMousePositionInWindow(c.WindowID); pos.Sub(c.Position).Len() > 1 {
+		c.MouseMoved(pos)
+	}
+	stick := a.Controller.GetControllerStickRaw(c.ControllerID, StickLeft)
+	button := a.Controller.GetControllerButtonState(c.ControllerID, c.Button) == DownPosition
+	before := c.Position
+	pos, action := c.Update(stick, button, dt, Rect2D{ZeroVec2, Vec2{float32(size.X()), float32(size.Y())}})
+	if pos != before {
+		a.Mouse.SetCursorPosition(c.WindowID, pos)
+	}
+	if action != InputUntouched {
+		a.Mouse.SendMouseButton(Mouse1, action)
+	}
 }
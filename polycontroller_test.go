@@ -0,0 +1,143 @@
+package polyapp
+
+import (
+	"testing"
+)
+
+func TestApplyDeadzone(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float32
+		deadzone float32
+		want     float32
+	}{
+		{"center", 0, 0.2, 0},
+		{"inside", 0.1, 0.2, 0},
+		{"boundary", 0.2, 0.2, 0},
+		{"negative boundary", -0.2, 0.2, 0},
+		{"just past", 0.3, 0.2, 0.125},
+		{"just past negative", -0.3, 0.2, -0.125},
+		{"full deflection", 1, 0.2, 1},
+		{"full negative deflection", -1, 0.2, -1},
+		{"past full deflection", 1.5, 0.2, 1},
+		{"no deadzone", 0.5, 0, 0.5},
+		{"negative deadzone", 0.5, -0.3, 0.5},
+		{"negative deadzone center", 0, -0.3, 0},
+		{"whole range deadzone", 0.9, 1, 0},
+	}
+	for _, tt := range tests {
+		if got := ApplyDeadzone(tt.value, tt.deadzone); !near(got, tt.want) {
+			t.Errorf("%s: ApplyDeadzone(%v, %v) = %v, want %v", tt.name, tt.value, tt.deadzone, got, tt.want)
+		}
+	}
+}
+
+func TestApplyRadialDeadzone(t *testing.T) {
+	tests := []struct {
+		name     string
+		stick    Vec2
+		deadzone float32
+		want     Vec2
+	}{
+		{"center", Vec2{0, 0}, 0.2, Vec2{0, 0}},
+		{"inside", Vec2{0.1, 0.1}, 0.2, Vec2{0, 0}},
+		{"boundary", Vec2{0, -0.2}, 0.2, Vec2{0, 0}},
+		{"just past", Vec2{0.3, 0}, 0.2, Vec2{0.125, 0}},
+		// 0.6 from the center along a diagonal keeps its direction
+		{"just past diagonal", Vec2{0.3, 0.3}.Norm().Scale(0.6), 0.2, Vec2{0.5, 0.5}.Norm().Scale(0.5)},
+		{"full deflection", Vec2{0, 1}, 0.2, Vec2{0, 1}},
+		{"full diagonal deflection", Vec2{1, -1}, 0.2, Vec2{1, -1}.Norm()},
+		{"negative deadzone center", Vec2{0, 0}, -0.1, Vec2{0, 0}},
+		{"negative deadzone", Vec2{0.5, 0}, -0.1, Vec2{0.5, 0}},
+	}
+	for _, tt := range tests {
+		got := ApplyRadialDeadzone(tt.stick, tt.deadzone)
+		if !near(got.X(), tt.want.X()) || !near(got.Y(), tt.want.Y()) {
+			t.Errorf("%s: ApplyRadialDeadzone(%v, %v) = %v, want %v", tt.name, tt.stick, tt.deadzone, got, tt.want)
+		}
+	}
+}
+
+type testController struct {
+	ControllerInterface
+	stick  Vec2
+	button InputState
+}
+
+func (c *testController) GetControllerStickRaw(controllerID uint8, stick ControllerStick) Vec2 {
+	return c.stick
+}
+
+func (c *testController) GetControllerButtonState(controllerID uint8, button ControllerButton) InputState {
+	return c.button
+}
+
+type testMouse struct {
+	MouseInterface
+	pos     Vec2
+	actions []InputAction
+}
+
+func (m *testMouse) GetMousePositionInWindow(windowID uint8) (Vec2, bool) {
+	return m.pos, true
+}
+
+func (m *testMouse) SetCursorPosition(windowID uint8, pos Vec2) {
+	m.pos = pos
+}
+
+func (m *testMouse) SendMouseButton(button MouseButton, action InputAction) {
+	if button == Mouse1 {
+		m.actions = append(m.actions, action)
+	}
+}
+
+type testWindow struct {
+	WindowInterface
+}
+
+func (w testWindow) GetSize(windowID uint8) (IVec2, error) {
+	return IVec2{100, 50}, nil
+}
+
+func TestGamepadCursorDrivesMouse(t *testing.T) {
+	controller, mouse := &testController{}, &testMouse{pos: Vec2{10, 10}}
+	app := &App{
+		Window:     WindowProvider{testWindow{}},
+		Mouse:      MouseProvider{mouse},
+		Controller: ControllerProvider{ControllerInterface: controller},
+	}
+	app.EnableGamepadCursor(0, 40)
+	controller.stick = Vec2{1, 0}
+	app.UpdateGamepadCursor(0.5)
+	if mouse.pos != (Vec2{30, 10}) {
+		t.Errorf("cursor at %v after half a second at full deflection, want {30 10}", mouse.pos)
+	}
+	// the real mouse moves the cursor, the stick carries on from there and stops at the window's edge
+	mouse.pos = Vec2{90, 40}
+	controller.stick = Vec2{1, 1}.Norm()
+	app.UpdateGamepadCursor(1)
+	if mouse.pos != (Vec2{100, 50}) {
+		t.Errorf("cursor at %v, want it kept at the window's corner {100 50}", mouse.pos)
+	}
+	// a stick resting inside the deadzone does not move the cursor
+	controller.stick = Vec2{0.1, 0}
+	app.UpdateGamepadCursor(1)
+	if mouse.pos != (Vec2{100, 50}) {
+		t.Errorf("stick inside the deadzone moved the cursor to %v", mouse.pos)
+	}
+	controller.button = DownPosition
+	app.UpdateGamepadCursor(0.1)
+	app.UpdateGamepadCursor(0.1)
+	controller.button = UpPosition
+	app.UpdateGamepadCursor(0.1)
+	if want := []InputAction{InputPressed, InputReleased}; len(mouse.actions) != 2 || mouse.actions[0] != want[0] || mouse.actions[1] != want[1] {
+		t.Errorf("sent Mouse1 actions %v, want %v", mouse.actions, want)
+	}
+	app.DisableGamepadCursor()
+	controller.stick = Vec2{-1, 0}
+	app.UpdateGamepadCursor(1)
+	if mouse.pos != (Vec2{100, 50}) {
+		t.Errorf("disabled gamepad cursor moved the cursor to %v", mouse.pos)
+	}
+}
@@ -0,0 +1,140 @@
+package polyapp
+
+import (
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Number of line segments used by DebugCircle2D
+const debugCircleSegments = 32
+
+// Batches that Debug* shapes collect in until DrawDebug
+type debugDraw struct {
+	lines BatchID
+	text  BatchID
+	font  *FontAtlas
+	// renderers drawing the text batch, keyed by the renderer given to DrawDebug
+	textRenderers map[RendererID]RendererID
+	// errors from Debug* calls, reported by the next DrawDebug
+	errs DeepError
+}
+
+// Creates the batches used by the Debug* helpers. Until this is called they do nothing, so debug
+// drawing can be left in place and switched on when needed. font is used by DebugText2D and may be nil,
+// in which case debug text is skipped.
+func (g *GraphicsProvider) EnableDebugDraw(font *FontAtlas) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] EnableDebugDraw():")
+	dErr.IsErr = false
+	debug := &debugDraw{font: font, textRenderers: map[RendererID]RendererID{}}
+	debug.errs = utils.NewDeepError("[PolyApp] DrawDebug():")
+	debug.errs.IsErr = false
+	var err DeepError
+	debug.lines, err = g.AddDrawBatch(Pos2D|ColFA|Lines, 0, 256)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	if font != nil {
+		debug.text, err = g.AddDrawBatch(Pos2D|HasTex|ColFA, font.TextureID, 256)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return dErr
+		}
+	}
+	g.debug = debug
+	return dErr
+}
+
+// Queues a 1 pixel line from a to b for the next DrawDebug
+func (g GraphicsProvider) DebugLine2D(a Vec2, b Vec2, color ColorFA) {
+	g.debugStrip([]Vec2{a, b}, color)
+}
+
+// Queues the outline of rect for the next DrawDebug
+func (g GraphicsProvider) DebugRect2D(rect Rect2D, color ColorFA) {
+	quad := rect.Quad()
+	g.debugStrip([]Vec2{quad[0], quad[1], quad[2], quad[3], quad[0]}, color)
+}
+
+// Queues the outline of a circle for the next DrawDebug
+func (g GraphicsProvider) DebugCircle2D(center Vec2, radius float32, color ColorFA) {
+	points := make([]Vec2, debugCircleSegments+1)
+	for i := range points {
+		angle := float32(i) * 360 / debugCircleSegments
+		points[i] = Vec2{center.X() + radius*math.CosDeg(angle), center.Y() + radius*math.SinDeg(angle)}
+	}
+	g.debugStrip(points, color)
+}
+
+// Queues white text with its first line's top-left at pos for the next DrawDebug,
+// drawn with the font given to EnableDebugDraw at a scale of 1
+func (g GraphicsProvider) DebugText2D(pos Vec2, text string) {
+	if g.debug == nil || g.debug.font == nil {
+		return
+	}
+	_, err := g.AddText2D(g.debug.text, g.debug.font, text, pos, 1, ColorFA{1, 1, 1, 1})
+	g.debug.errs.AddChildDeepError(err)
+}
+
+func (g GraphicsProvider) debugStrip(points []Vec2, color ColorFA) {
+	if g.debug == nil {
+		return
+	}
+	verts := make([]Vertex, len(points))
+	for i, p := range points {
+		verts[i] = Vertex{Pos: p.AsVec3(), Color: color}
+	}
+	_, err := g.AddLineStrip2D(g.debug.lines, verts)
+	g.debug.errs.AddChildDeepError(err)
+}
+
+// Draws every shape queued by the Debug* helpers since the last call, then discards them.
+// rendererID must accept Pos2D|ColFA|Lines. Text needs a textured renderer, so it is drawn with one
+// created on first use for each rendererID, given rendererID's camera and coordinate convention before
+// every draw. Also returns any errors the Debug* helpers hit while queueing.
+func (g GraphicsProvider) DrawDebug(surfaceID SurfaceID, rendererID RendererID) DeepError {
+	if g.debug == nil {
+		return utils.NewDeepError("[PolyApp] DrawDebug(): debug drawing is not enabled, see EnableDebugDraw")
+	}
+	dErr := g.debug.errs
+	g.debug.errs = utils.NewDeepError("[PolyApp] DrawDebug():")
+	g.debug.errs.IsErr = false
+	dErr.AddChildDeepError(g.DrawBatch(g.debug.lines, surfaceID, rendererID, true))
+	dErr.AddChildDeepError(g.ClearBatch(g.debug.lines))
+	if g.debug.font != nil {
+		textRendererID, err := g.debugTextRenderer(rendererID)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+		} else {
+			dErr.AddChildDeepError(g.DrawBatch(g.debug.text, surfaceID, textRendererID, true))
+		}
+		dErr.AddChildDeepError(g.ClearBatch(g.debug.text))
+	}
+	return dErr
+}
+
+// Returns the renderer debug text is drawn with alongside rendererID, matching its camera
+func (g GraphicsProvider) debugTextRenderer(rendererID RendererID) (RendererID, DeepError) {
+	camera, err := g.GetCamera(rendererID)
+	if err.IsErr {
+		return 0, err
+	}
+	textRendererID, ok := g.debug.textRenderers[rendererID]
+	if !ok {
+		textRendererID, err = g.AddRenderer(Pos2D|HasTex|ColFA|camera.Mode&CamMask, nil)
+		if err.IsErr {
+			return 0, err
+		}
+		g.debug.textRenderers[rendererID] = textRendererID
+	}
+	switch camera.Mode & CamMask {
+	case Cam2D:
+		err = g.SetCamera2D(textRendererID, camera.Center, camera.Zoom, camera.Rotation)
+	case Cam3D:
+		err = g.SetCamera3D(textRendererID, camera.Position, camera.Target, camera.Up, camera.FOV, camera.Near, camera.Far)
+	}
+	if err.IsErr {
+		return 0, err
+	}
+	return textRendererID, g.SetRendererCoordinateConvention(textRendererID, camera.Convention)
+}
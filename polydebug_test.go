@@ -0,0 +1,49 @@
+package polyapp
+
+import (
+	"testing"
+)
+
+func TestDrawDebugDrawsTextWithMatchingRenderer(t *testing.T) {
+	g, n := newTestNull(t)
+	font := &FontAtlas{
+		LineHeight: 8,
+		Base:       6,
+		Glyphs:     map[rune]GlyphInfo{'A': {Region: Rect2D{{0, 0}, {4, 8}}, Advance: 5}},
+	}
+	mustOk(t, g.EnableDebugDraw(font))
+	surfaceID, _, err := g.AddDrawSurface(IVec2{16, 16}, 0)
+	mustOk(t, err)
+	rendererID, err := g.AddRenderer(Pos2D|ColFA|Lines|Cam2D, nil)
+	mustOk(t, err)
+	mustOk(t, g.SetRendererCoordinateConvention(rendererID, YDownOriginTopLeft))
+	mustOk(t, g.SetCamera2D(rendererID, Vec2{3, 4}, 2, 0))
+	for frame := 0; frame < 2; frame += 1 {
+		g.DebugLine2D(Vec2{0, 0}, Vec2{4, 4}, testRed)
+		g.DebugText2D(Vec2{1, 1}, "A")
+		n.Log = nil
+		mustOk(t, g.DrawDebug(surfaceID, rendererID))
+		draws := loggedCalls(n, "DrawBatch")
+		if len(draws) != 2 {
+			t.Fatalf("logged %d draws, want the line and text batches", len(draws))
+		}
+		if draws[0].BatchID != g.debug.lines || draws[0].RendererID != rendererID {
+			t.Errorf("first draw %+v, want the line batch with the given renderer", draws[0])
+		}
+		if draws[1].BatchID != g.debug.text || draws[1].RendererID == rendererID {
+			t.Errorf("second draw %+v, want the text batch with a textured renderer", draws[1])
+		}
+		want, _ := g.GetCamera(rendererID)
+		if got, _ := g.GetCamera(draws[1].RendererID); got != want {
+			t.Errorf("text renderer camera %+v, want %+v", got, want)
+		}
+		for _, batchID := range []BatchID{g.debug.lines, g.debug.text} {
+			if count, _ := g.GetBatchShapeCount(batchID); count != 0 {
+				t.Errorf("batch %d still holds %d shapes after DrawDebug", batchID, count)
+			}
+		}
+	}
+	if len(g.debug.textRenderers) != 1 {
+		t.Errorf("created %d text renderers for one renderer", len(g.debug.textRenderers))
+	}
+}
@@ -0,0 +1,100 @@
+package polyapp
+
+import (
+	"strings"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+// How serious a DeepError is.
+//
+// DeepError comes from genutils and has no room for a severity, so the severity of info and warning
+// DeepErrors is recorded as a tag at the start of their Text. IsErr is only ever set for SeverityError,
+// so checking IsErr (or HasErrors) keeps meaning "an error is present".
+type Severity uint8
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+const (
+	infoTag    = "[Info] "
+	warningTag = "[Warning] "
+)
+
+// Creates a DeepError with the given severity. Only SeverityError sets IsErr.
+func NewSeverityError(text string, severity Severity) DeepError {
+	switch severity {
+	case SeverityInfo:
+		text = infoTag + text
+	case SeverityWarning:
+		text = warningTag + text
+	}
+	dErr := utils.NewDeepError(text)
+	dErr.IsErr = severity == SeverityError
+	return dErr
+}
+
+func GetSeverity(err DeepError) Severity {
+	switch {
+	case err.IsErr:
+		return SeverityError
+	case strings.HasPrefix(err.Text, warningTag):
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// Same as parent.AddChildDeepError(child), but also keeps children that are tagged info or warning
+// (or contain warnings) without marking the parent as an error
+func AddChildWithSeverity(parent *DeepError, child DeepError) {
+	if child.IsErr {
+		parent.AddChildDeepError(child)
+		return
+	}
+	if !strings.HasPrefix(child.Text, infoTag) && !HasWarnings(child) {
+		return
+	}
+	parent.Total += child.Total
+	parent.Children = append(parent.Children, child)
+}
+
+func HasErrors(err DeepError) bool {
+	return err.IsErr
+}
+
+// Reports whether err or any of its children is a warning
+func HasWarnings(err DeepError) bool {
+	if GetSeverity(err) == SeverityWarning {
+		return true
+	}
+	for _, child := range err.Children {
+		if HasWarnings(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// Renders err and all of its children as one line per DeepError, each child indented by one more
+// prefix than its parent. Unlike err.Error(), info and warning DeepErrors are included.
+func FlattenDeepError(err DeepError, prefix string) string {
+	builder := strings.Builder{}
+	flattenDeepError(&builder, err, prefix, 0)
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func flattenDeepError(builder *strings.Builder, err DeepError, prefix string, depth int) {
+	if !err.IsErr && !strings.HasPrefix(err.Text, infoTag) && !HasWarnings(err) {
+		return
+	}
+	builder.WriteString(strings.Repeat(prefix, depth))
+	builder.WriteString(err.Text)
+	builder.WriteString("\n")
+	for _, child := range err.Children {
+		flattenDeepError(builder, child, prefix, depth+1)
+	}
+}
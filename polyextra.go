@@ -0,0 +1,117 @@
+package polyapp
+
+import (
+	"fmt"
+	gomath "math"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Names the uint32 blocks of VertExtra so shaders and the code filling vertices agree on what each
+// block holds. Blocks are assigned in the order the names are given, block 0 first, and float values
+// are stored as their IEEE 754 bits.
+type ExtraLayout struct {
+	blocks map[string]uint32
+}
+
+// Creates a layout naming the first len(names) blocks, returning a DeepError if vertexFlags has fewer
+// extra blocks (Ex32..Ex256) than names or a name is repeated
+func NewExtraLayout(vertexFlags VertexFlags, names ...string) (ExtraLayout, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] NewExtraLayout():")
+	dErr.IsErr = false
+	available := vertexFlags.ExSize() / 4
+	if uint32(len(names)) > available {
+		dErr.AddChildError(fmt.Errorf("%d names given but vertex flags only have %d extra blocks", len(names), available))
+		return ExtraLayout{}, dErr
+	}
+	layout := ExtraLayout{blocks: make(map[string]uint32, len(names))}
+	for i, name := range names {
+		if _, ok := layout.blocks[name]; ok {
+			dErr.AddChildError(fmt.Errorf("name %q is used more than once", name))
+			return ExtraLayout{}, dErr
+		}
+		layout.blocks[name] = uint32(i)
+	}
+	return layout, dErr
+}
+
+// Returns the block index of name, false if the layout does not have it
+func (l ExtraLayout) Block(name string) (uint32, bool) {
+	block, ok := l.blocks[name]
+	return block, ok
+}
+
+func (l ExtraLayout) block(method string, name string) (uint32, DeepError) {
+	dErr := utils.NewDeepError(fmt.Sprintf("[PolyApp] ExtraLayout.%s():", method))
+	dErr.IsErr = false
+	block, ok := l.blocks[name]
+	if !ok {
+		dErr.AddChildError(fmt.Errorf("layout has no field %q", name))
+	}
+	return block, dErr
+}
+
+func (l ExtraLayout) SetFloat(extra *VertExtra, name string, value float32) DeepError {
+	block, err := l.block("SetFloat", name)
+	if err.IsErr {
+		return err
+	}
+	extra[block] = gomath.Float32bits(value)
+	return err
+}
+
+func (l ExtraLayout) SetUint(extra *VertExtra, name string, value uint32) DeepError {
+	block, err := l.block("SetUint", name)
+	if err.IsErr {
+		return err
+	}
+	extra[block] = value
+	return err
+}
+
+func (l ExtraLayout) Float(extra VertExtra, name string) (float32, DeepError) {
+	block, err := l.block("Float", name)
+	if err.IsErr {
+		return 0, err
+	}
+	return gomath.Float32frombits(extra[block]), err
+}
+
+func (l ExtraLayout) Uint(extra VertExtra, name string) (uint32, DeepError) {
+	block, err := l.block("Uint", name)
+	if err.IsErr {
+		return 0, err
+	}
+	return extra[block], err
+}
+
+// Fills a VertExtra one named field at a time for passing to the shape helpers, for example
+//
+//	extra, err := layout.Builder().SetExtra("glowIntensity", 0.5).SetExtraUint("tileIndex", 3).Build()
+type ExtraBuilder struct {
+	layout ExtraLayout
+	extra  VertExtra
+	dErr   DeepError
+}
+
+// Starts an ExtraBuilder with every block zero
+func (l ExtraLayout) Builder() *ExtraBuilder {
+	dErr := utils.NewDeepError("[PolyApp] ExtraBuilder.Build():")
+	dErr.IsErr = false
+	return &ExtraBuilder{layout: l, dErr: dErr}
+}
+
+func (b *ExtraBuilder) SetExtra(name string, value float32) *ExtraBuilder {
+	b.dErr.AddChildDeepError(b.layout.SetFloat(&b.extra, name, value))
+	return b
+}
+
+func (b *ExtraBuilder) SetExtraUint(name string, value uint32) *ExtraBuilder {
+	b.dErr.AddChildDeepError(b.layout.SetUint(&b.extra, name, value))
+	return b
+}
+
+// Returns the filled VertExtra, and a DeepError listing every name the layout did not have
+func (b *ExtraBuilder) Build() (VertExtra, DeepError) {
+	return b.extra, b.dErr
+}
@@ -0,0 +1,147 @@
+package polyapp
+
+// A focusable area of the screen and the ID the app uses to recognise it
+type FocusRegion struct {
+	ID   uint32
+	Rect Rect2D
+}
+
+// Tracks which of an ordered list of regions has keyboard focus, moving it with Tab and Shift+Tab and on
+// clicks. It does not install callbacks itself: call KeyPressed from the SetCallbackOnKeyPress callback and
+// MouseClicked from the SetCallbackOnMouseClick callback, skipping the app's own handling when they return true.
+//
+// Text input is not routed automatically. The callback set with SetCallbackOnRuneInput receives text as
+// committed by the platform, including text composed with an input method (IME), so pass each rune to the
+// region CurrentFocus returns. Backends may also report a '\t' rune for a Tab that KeyPressed used to move
+// focus, which text regions should ignore.
+type FocusManager struct {
+	regions []FocusRegion
+	// index into regions, -1 when nothing has focus
+	focus int
+	// Called with the previous and new focus after every change, hadFocus and hasFocus are false when
+	// nothing had or has focus
+	OnFocusChange func(prev uint32, hadFocus bool, next uint32, hasFocus bool)
+}
+
+func NewFocusManager() *FocusManager {
+	return &FocusManager{focus: -1}
+}
+
+// Adds a region at the end of the tab order, or moves the region with the same ID to rect keeping its place
+func (f *FocusManager) Add(id uint32, rect Rect2D) {
+	if i := f.index(id); i >= 0 {
+		f.regions[i].Rect = rect
+		return
+	}
+	f.regions = append(f.regions, FocusRegion{ID: id, Rect: rect})
+}
+
+// Removes the region, clearing the focus if it had it
+func (f *FocusManager) Remove(id uint32) {
+	i := f.index(id)
+	if i < 0 {
+		return
+	}
+	if i == f.focus {
+		f.move(-1)
+	}
+	f.regions = append(f.regions[:i], f.regions[i+1:]...)
+	if f.focus > i {
+		f.focus -= 1
+	}
+}
+
+// Returns the regions in tab order
+func (f *FocusManager) Regions() []FocusRegion {
+	return f.regions
+}
+
+// Returns the ID of the focused region, or false if nothing has focus
+func (f *FocusManager) CurrentFocus() (uint32, bool) {
+	if f.focus < 0 {
+		return 0, false
+	}
+	return f.regions[f.focus].ID, true
+}
+
+// Focuses the region with the given ID, returning false without changing the focus if there is none
+func (f *FocusManager) SetFocus(id uint32) bool {
+	i := f.index(id)
+	if i < 0 {
+		return false
+	}
+	f.move(i)
+	return true
+}
+
+func (f *FocusManager) ClearFocus() {
+	f.move(-1)
+}
+
+// Moves the focus to the next region in tab order, wrapping around, or the first if nothing has focus
+func (f *FocusManager) FocusNext() {
+	if len(f.regions) > 0 {
+		f.move((f.focus + 1) % len(f.regions))
+	}
+}
+
+// Moves the focus to the previous region in tab order, wrapping around, or the last if nothing has focus
+func (f *FocusManager) FocusPrevious() {
+	if len(f.regions) == 0 {
+		return
+	}
+	if f.focus <= 0 {
+		f.move(len(f.regions) - 1)
+		return
+	}
+	f.move(f.focus - 1)
+}
+
+// Moves the focus for Tab (forward) and Shift+Tab (backward) presses and repeats, returning true if the
+// key was used
+func (f *FocusManager) KeyPressed(key KeyboardKey, state InputAction, mods KeyboardMod) bool {
+	if key != KeyTab || (state != InputPressed && state != InputHeldRepeat) || len(f.regions) == 0 {
+		return false
+	}
+	if mods&ModShift != 0 {
+		f.FocusPrevious()
+	} else {
+		f.FocusNext()
+	}
+	return true
+}
+
+// Focuses the region under pos, returning true if there is one. Where regions overlap the one added last
+// wins, as it is usually drawn on top. Clicking outside every region clears the focus.
+func (f *FocusManager) MouseClicked(pos Vec2) bool {
+	for i := len(f.regions) - 1; i >= 0; i -= 1 {
+		rect := f.regions[i].Rect
+		if pos.X() >= rect[0].X() && pos.X() < rect[1].X() && pos.Y() >= rect[0].Y() && pos.Y() < rect[1].Y() {
+			f.move(i)
+			return true
+		}
+	}
+	f.move(-1)
+	return false
+}
+
+func (f *FocusManager) index(id uint32) int {
+	for i, region := range f.regions {
+		if region.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *FocusManager) move(to int) {
+	if to == f.focus {
+		return
+	}
+	prev, hadFocus := f.CurrentFocus()
+	f.focus = to
+	if f.OnFocusChange != nil {
+		next, hasFocus := f.CurrentFocus()
+		f.OnFocusChange(prev, hadFocus, next, hasFocus)
+	}
+}
@@ -0,0 +1,47 @@
+package polyapp
+
+import utils "github.com/gabe-lee/genutils"
+
+// Wraps the usual order of a frame on one surface: BeginFrame clears it, Draw draws batches onto it in
+// order, and End draws any queued debug shapes on top and presents it. Errors are collected along the way
+// and returned by End, so a frame reads without error checks between calls. The underlying ClearSurface,
+// DrawBatch and PresentSurface calls remain available for frames that need a different order.
+type FrameContext struct {
+	graphics      GraphicsProvider
+	surfaceID     SurfaceID
+	debug         bool
+	debugRenderer RendererID
+	dErr          DeepError
+}
+
+// Starts a frame on surfaceID by clearing it to clearColor
+func (g GraphicsProvider) BeginFrame(surfaceID SurfaceID, clearColor ColorFA) *FrameContext {
+	dErr := utils.NewDeepError("[PolyApp] FrameContext:")
+	dErr.IsErr = false
+	dErr.AddChildDeepError(g.ClearSurface(surfaceID, clearColor))
+	return &FrameContext{
+		graphics:  g,
+		surfaceID: surfaceID,
+		dErr:      dErr,
+	}
+}
+
+// Draws every shape of the batch onto the frame's surface
+func (f *FrameContext) Draw(batchID BatchID, rendererID RendererID) {
+	f.dErr.AddChildDeepError(f.graphics.DrawBatch(batchID, f.surfaceID, rendererID, true))
+}
+
+// Makes End call DrawDebug with rendererID before presenting, so debug shapes end up above everything else
+func (f *FrameContext) DrawDebugOnEnd(rendererID RendererID) {
+	f.debug = true
+	f.debugRenderer = rendererID
+}
+
+// Finishes the frame and presents the surface, returning every error hit since BeginFrame
+func (f *FrameContext) End() DeepError {
+	if f.debug {
+		f.dErr.AddChildDeepError(f.graphics.DrawDebug(f.surfaceID, f.debugRenderer))
+	}
+	f.dErr.AddChildDeepError(f.graphics.PresentSurface(f.surfaceID))
+	return f.dErr
+}
@@ -0,0 +1,259 @@
+package polyapp
+
+import (
+	"sort"
+
+	geom "github.com/gabe-lee/gengeom"
+	math "github.com/gabe-lee/genmath"
+)
+
+// The Build* functions return the same vertices and indexes the matching Add* helper writes to a batch,
+// without needing a backend, so geometry can be precomputed, cached or checked in isolation and later
+// added with AddShapeWithVertices. Vertex normals are taken from the input vertices (or left zero),
+// where the Add* helpers point them towards the viewer according to XRightYUpZAway().
+
+// Builds the geometry of AddRegularPolygon2D. Vertices take their normal, color and extra from center.
+func BuildRegularPolygon2D(center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) ([]Vertex, []uint32) {
+	points := geom.PointsOnCircle(shapeRotation*math.DEG_TO_RAD, radius, center.Pos.AsVec2(), sides)
+	uvs := geom.PointsOnCircle(uvRotation*math.DEG_TO_RAD, uvRadius, center.UV, sides)
+	return regularPolygonVertices(center, points, uvs), regularPolygonPrototype(sides).Indexes
+}
+
+// Builds the geometry of AddRegularPolygonRing2D. Vertices take their normal, color and extra from center.
+func BuildRegularPolygonRing2D(center Vertex, sides uint32, innerRadius float32, outerRadius float32, shapeRotation float32, uvInnerRadius float32, uvOuterRadius float32, uvRotation float32) ([]Vertex, []uint32) {
+	points := geom.PointsOnRing(shapeRotation*math.DEG_TO_RAD, innerRadius, outerRadius, center.Pos.AsVec2(), sides)
+	uvs := geom.PointsOnRing(uvRotation*math.DEG_TO_RAD, uvInnerRadius, uvOuterRadius, center.UV, sides)
+	return ringVertices(center, points, uvs), regularPolygonRingPrototype(sides).Indexes
+}
+
+// Builds the geometry of AddQuad2D, with zero normals
+func BuildQuad2D(quad Quad2D, color ColorFA, uvQuad Quad2D, extra VertExtra) ([]Vertex, []uint32) {
+	return quadVertices(quad, color, uvQuad, extra, ZeroVec3), quadPrototype().Indexes
+}
+
+// Builds the geometry of AddRect2D, with zero normals
+func BuildRect2D(rect Rect2D, color ColorFA, uvRect Rect2D, extra VertExtra) ([]Vertex, []uint32) {
+	return BuildQuad2D(rect.Quad(), color, uvRect.Quad(), extra)
+}
+
+// The center vertex followed by one vertex per point
+func regularPolygonVertices(center Vertex, points []Vec2, uvs []Vec2) []Vertex {
+	vertices := make([]Vertex, 0, len(points)+1)
+	vertices = append(vertices, center)
+	for i := range points {
+		center.Pos = points[i].AsVec3()
+		center.UV = uvs[i]
+		vertices = append(vertices, center)
+	}
+	return vertices
+}
+
+// One vertex per point, points alternating inner and outer as returned by pointsOnRing
+func ringVertices(center Vertex, points []Vec2, uvs []Vec2) []Vertex {
+	vertices := make([]Vertex, len(points))
+	for i := range points {
+		center.Pos = points[i].AsVec3()
+		center.UV = uvs[i]
+		vertices[i] = center
+	}
+	return vertices
+}
+
+func quadVertices(quad Quad2D, color ColorFA, uvQuad Quad2D, extra VertExtra, norm Vec3) []Vertex {
+	vertices := make([]Vertex, 4)
+	for i := range vertices {
+		vertices[i] = Vertex{Pos: quad[i].AsVec3(), Norm: norm, UV: uvQuad[i], Color: color, Extra: extra}
+	}
+	return vertices
+}
+
+// Triangulates the region enclosed by contours using the even-odd rule: contours inside an odd number of other
+// contours are holes. Each contour is a closed loop of points in either winding, the last point joining back to
+// the first, and contours must not cross themselves or each other. Returns every contour point (contour order,
+// duplicate neighbours removed) and counter-clockwise triangle indexes into them, using ear clipping.
+func TriangulatePolygon2D(contours [][]Vec2) ([]Vec2, []uint32) {
+	points := make([]Vec2, 0)
+	loops := make([][]uint32, 0, len(contours))
+	for _, contour := range contours {
+		loop := make([]uint32, 0, len(contour))
+		for i, p := range contour {
+			if p.Equals(contour[(i+1)%len(contour)]) {
+				continue
+			}
+			loop = append(loop, uint32(len(points)))
+			points = append(points, p)
+		}
+		if len(loop) >= 3 {
+			loops = append(loops, loop)
+		}
+	}
+	depths := make([]int, len(loops))
+	for i := range loops {
+		for j := range loops {
+			if i != j && polygonContains(points, loops[j], points[loops[i][0]]) {
+				depths[i] += 1
+			}
+		}
+	}
+	indexes := make([]uint32, 0, 3*len(points))
+	for i, outer := range loops {
+		if depths[i]%2 == 1 {
+			continue
+		}
+		polygon := windLoop(points, outer, true)
+		holes := make([][]uint32, 0)
+		for j, hole := range loops {
+			if depths[j] == depths[i]+1 && polygonContains(points, outer, points[hole[0]]) {
+				holes = append(holes, windLoop(points, hole, false))
+			}
+		}
+		// bridging the rightmost holes first keeps each bridge from crossing holes not yet merged
+		sort.Slice(holes, func(a, b int) bool {
+			return loopMaxX(points, holes[a]) > loopMaxX(points, holes[b])
+		})
+		for h, hole := range holes {
+			polygon = bridgeHole(points, polygon, hole, holes[h+1:])
+		}
+		indexes = earClip(points, polygon, indexes)
+	}
+	return points, indexes
+}
+
+func loopArea(points []Vec2, loop []uint32) float32 {
+	area := float32(0)
+	for i := range loop {
+		area += points[loop[i]].Cross(points[loop[(i+1)%len(loop)]])
+	}
+	return area / 2
+}
+
+func loopMaxX(points []Vec2, loop []uint32) float32 {
+	maxX := points[loop[0]].X()
+	for _, i := range loop {
+		maxX = math.Max(maxX, points[i].X())
+	}
+	return maxX
+}
+
+// Returns loop wound counter-clockwise when ccw is true, otherwise clockwise
+func windLoop(points []Vec2, loop []uint32, ccw bool) []uint32 {
+	if (loopArea(points, loop) > 0) == ccw {
+		return loop
+	}
+	reversed := make([]uint32, len(loop))
+	for i := range loop {
+		reversed[i] = loop[len(loop)-1-i]
+	}
+	return reversed
+}
+
+// Even-odd point in polygon test
+func polygonContains(points []Vec2, loop []uint32, p Vec2) bool {
+	inside := false
+	for i := range loop {
+		a, b := points[loop[i]], points[loop[(i+1)%len(loop)]]
+		if (a.Y() > p.Y()) != (b.Y() > p.Y()) && p.X() < a.X()+(p.Y()-a.Y())/(b.Y()-a.Y())*(b.X()-a.X()) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Reports whether segments ab and cd cross at a point other than a shared endpoint
+func segmentsCross(a Vec2, b Vec2, c Vec2, d Vec2) bool {
+	if a.Equals(c) || a.Equals(d) || b.Equals(c) || b.Equals(d) {
+		return false
+	}
+	d1 := b.Sub(a).Cross(c.Sub(a))
+	d2 := b.Sub(a).Cross(d.Sub(a))
+	d3 := d.Sub(c).Cross(a.Sub(c))
+	d4 := d.Sub(c).Cross(b.Sub(c))
+	return ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0)) && d1 != 0 && d2 != 0 && d3 != 0 && d4 != 0
+}
+
+// Joins a clockwise hole into a counter-clockwise polygon with a pair of coincident edges from the hole's
+// rightmost point to the nearest polygon point it can see, giving a single loop that ear clipping can handle
+func bridgeHole(points []Vec2, polygon []uint32, hole []uint32, remaining [][]uint32) []uint32 {
+	m := 0
+	for i := range hole {
+		if points[hole[i]].X() > points[hole[m]].X() {
+			m = i
+		}
+	}
+	holePoint := points[hole[m]]
+	visible := func(p Vec2) bool {
+		for _, loop := range append([][]uint32{polygon, hole}, remaining...) {
+			for i := range loop {
+				if segmentsCross(holePoint, p, points[loop[i]], points[loop[(i+1)%len(loop)]]) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	best, bestDist := -1, float32(0)
+	for i, idx := range polygon {
+		p := points[idx]
+		dist := p.Sub(holePoint).Len()
+		if p.X() < holePoint.X() || (best >= 0 && dist >= bestDist) || !visible(p) {
+			continue
+		}
+		best, bestDist = i, dist
+	}
+	if best < 0 {
+		best = 0
+		for i, idx := range polygon {
+			if points[idx].Sub(holePoint).Len() < points[polygon[best]].Sub(holePoint).Len() {
+				best = i
+			}
+		}
+	}
+	merged := make([]uint32, 0, len(polygon)+len(hole)+2)
+	merged = append(merged, polygon[:best+1]...)
+	for i := 0; i <= len(hole); i += 1 {
+		merged = append(merged, hole[(m+i)%len(hole)])
+	}
+	merged = append(merged, polygon[best:]...)
+	return merged
+}
+
+// Reports whether p is strictly inside the counter-clockwise triangle abc
+func triangleStrictlyContains(a Vec2, b Vec2, c Vec2, p Vec2) bool {
+	return b.Sub(a).Cross(p.Sub(a)) > 0 && c.Sub(b).Cross(p.Sub(b)) > 0 && a.Sub(c).Cross(p.Sub(c)) > 0
+}
+
+// Appends the triangles of the counter-clockwise polygon to indexes, cutting off one convex corner (ear) at a time
+func earClip(points []Vec2, polygon []uint32, indexes []uint32) []uint32 {
+	loop := append([]uint32(nil), polygon...)
+	stalled := 0
+	for i := 0; len(loop) > 3; {
+		i %= len(loop)
+		prev, cur, next := loop[(i+len(loop)-1)%len(loop)], loop[i], loop[(i+1)%len(loop)]
+		a, b, c := points[prev], points[cur], points[next]
+		turn := b.Sub(a).Cross(c.Sub(b))
+		ear := turn > 0
+		for j := 0; ear && j < len(loop); j += 1 {
+			p := points[loop[j]]
+			if !p.Equals(a) && !p.Equals(b) && !p.Equals(c) && triangleStrictlyContains(a, b, c, p) {
+				ear = false
+			}
+		}
+		// collinear corners add no area, and after a full pass without an ear the polygon is not simple
+		// (usually from rounding), so cut the next convex corner anyway rather than looping forever
+		force := stalled > len(loop) && (turn > 0 || stalled > 2*len(loop))
+		if ear || turn == 0 || force {
+			if turn > 0 {
+				indexes = append(indexes, prev, cur, next)
+			}
+			loop = append(loop[:i], loop[i+1:]...)
+			stalled = 0
+			continue
+		}
+		stalled += 1
+		i += 1
+	}
+	if len(loop) == 3 && points[loop[1]].Sub(points[loop[0]]).Cross(points[loop[2]].Sub(points[loop[1]])) > 0 {
+		indexes = append(indexes, loop[0], loop[1], loop[2])
+	}
+	return indexes
+}
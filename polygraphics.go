@@ -1,6 +1,13 @@
 package polyapp
 
 import (
+	"fmt"
+	"image"
+	"image/draw"
+	gomath "math"
+	"sort"
+	"sync"
+
 	geom "github.com/gabe-lee/gengeom"
 	math "github.com/gabe-lee/genmath"
 	utils "github.com/gabe-lee/genutils"
@@ -9,28 +16,231 @@ import (
 type GraphicsInterface interface {
 	XRightYUpZAway() Vec3
 
+	// Compiles a single shader stage, returning the compiled bytes or a DeepError containing the
+	// compiler log (with line numbers) on failure. The shader itself is not modified.
+	CompileShader(shader *Shader) (compiledBytes []byte, err DeepError)
 	AddRenderer(vertexFlags VertexFlags, shaders []*Shader) (RendererID, DeepError)
+	// Frees the renderer, its ID may be reused by a later AddRenderer
+	DeleteRenderer(rendererID RendererID) DeepError
+	// Batches using a texture added with Texture.Premultiply start with BlendPremultipliedAlpha, others with BlendAlpha
 	AddDrawBatch(vertexFlags VertexFlags, textureID TextureID, initialSize uint32) (BatchID, DeepError)
+	// Frees the batch and every shape in it. Its ID may be reused by a later AddDrawBatch, but shapes of
+	// the deleted batch stay invalid. Batches returned by GetOrCreateBatch must not be deleted.
+	DeleteBatch(batchID BatchID) DeepError
+	GetBatchVertexFlags(batchID BatchID) (VertexFlags, DeepError)
+	SetBatchBlendMode(batchID BatchID, mode BlendMode) DeepError
+	// Multiplies every color the batch outputs by tint at draw time (default {1, 1, 1, 1}).
+	// NoCol batches have no per-vertex color, so for them the tint is used as the base color.
+	SetBatchTint(batchID BatchID, tint ColorFA) DeepError
+	// Returns the largest resources the backend can create, check these before creating textures,
+	// surfaces or multisampled surfaces to adapt to the hardware instead of failing
+	GetGraphicsLimits() GraphicsLimits
 	AddTexture(texture *Texture) (TextureID, DeepError)
+	// Reserves a TextureID bound to a 1x1 white placeholder and returns immediately, decoding texture on a
+	// worker goroutine. Batches using the ID draw with the placeholder until the real texture is ready.
+	// The channel receives one result (nil IsErr on success) and is then closed, on failure the placeholder stays.
+	//
+	// The worker only decodes into memory, it never touches backend state: the decoded texture is uploaded
+	// by the goroutine that owns the backend during its next draw call, which is when the channel is
+	// signaled. texture must not be modified until then.
+	AddTextureAsync(texture *Texture) (TextureID, <-chan DeepError)
 	AddDrawSurface(size IVec2, mipMaps uint32) (SurfaceID, TextureID, DeepError)
+	AddDrawSurfaceWithDepth(size IVec2, mipMaps uint32) (SurfaceID, TextureID, DeepError)
+	// Frees the surface along with its depth buffer, stencil mask and, for surfaces from AddDrawSurface or
+	// AddDrawSurfaceWithDepth, its texture, which must not be drawn with afterwards. Surface and texture IDs
+	// are not reused, so a deleted ID keeps returning a DeepError.
+	DeleteSurface(surfaceID SurfaceID) DeepError
+	// Creates a multisampled surface that stores samples coverage samples per pixel, smoothing the edges of
+	// everything drawn to it. samples must be 1, 2, 4, 8 or 16 and no more than GetGraphicsLimits().MaxSamples
+	// (see ClampMSAASamples). Multisampled surfaces have no texture: to sample
+	// what was drawn, resolve it with ResolveSurface into a surface from AddDrawSurface and sample that one's texture.
+	AddDrawSurfaceMSAA(size IVec2, mipMaps uint32, samples uint32) (SurfaceID, DeepError)
+	// Averages the samples of each pixel of the multisampled surface src into dst, a surface of the same
+	// size from AddDrawSurface or AddDrawSurfaceWithDepth. Must be called after drawing to src and before
+	// dst's texture is sampled.
+	ResolveSurface(src SurfaceID, dst SurfaceID) DeepError
+	// Returns a surface that presents into the window's swapchain, for apps with more than one window.
+	// The surface follows the window's size and has no texture, so it cannot be sampled.
+	//
+	// Textures, renderers, batches and draw surfaces are shared by every window, so the same batch
+	// can be drawn to several window surfaces in one frame without duplicating its data.
+	AddWindowSurface(windowID uint8) (SurfaceID, DeepError)
+	// Shows what has been drawn to a window surface since the last present, swapping the window's buffers.
+	// Draw and clear calls only render into the surface's back buffer, so a frame can draw any number of
+	// batches and present once without the window ever showing a partly drawn frame. The swap waits for
+	// the number of vertical blanks set with SetSwapInterval, so with the default of 1 this blocks until
+	// the display's next refresh and presenting once per frame paces the app to the refresh rate.
+	// Surfaces that are not window surfaces have nothing to present, so this only checks that they exist.
+	PresentSurface(surfaceID SurfaceID) DeepError
+	// Sets how many vertical blanks PresentSurface waits for before swapping a window surface's buffers:
+	// 1 (the default) syncs presents to the display's refresh (VSync), 2 or more present at a fraction of
+	// the refresh rate, and 0 swaps immediately, which may tear. Only window surfaces are presented, so
+	// other surfaces return a DeepError.
+	SetSwapInterval(surfaceID SurfaceID, interval uint32) DeepError
+	GetSurfaceSize(surfaceID SurfaceID) (IVec2, DeepError)
 
 	ClearSurface(surfaceID SurfaceID, baseColor ColorFA) DeepError
 	ClearSurfaceArea(surfaceID SurfaceID, baseColor ColorFA, area IRect2D) DeepError
+	// Makes draws to the surface render into viewport (in pixels from the top-left corner) as if it were the
+	// whole surface: clip space and camera projections span the viewport and nothing is drawn outside it.
+	// Clearing still covers the whole surface, so with a viewport from ComputeLetterbox the bars stay the
+	// clear color. WorldToScreen and ScreenToWorld follow the viewport. An empty viewport (the default)
+	// restores the whole surface, and a viewport reaching outside the surface is cut to it when drawing.
+	SetViewport(surfaceID SurfaceID, viewport IRect2D) DeepError
+	// Returns the surface's viewport, the whole surface if none is set
+	GetViewport(surfaceID SurfaceID) (IRect2D, DeepError)
+	// Clears only the chosen parts of the surface: its color to color, its depth buffer to depth (1 is the
+	// far plane) and its stencil mask to stencil. Masks keep one bit per pixel, so a stencil of 0 leaves no
+	// pixel marked and any other value marks them all, the mask keeping the keepInside given to EndStencilMask.
+	// Depth buffers and masks the surface does not have are skipped, and clearing the mask while it is being
+	// drawn returns a DeepError.
+	ClearSurfaceFull(surfaceID SurfaceID, color ColorFA, depth float32, stencil uint8, clearColor bool, clearDepth bool, clearStencil bool) DeepError
+	// Starts drawing a stencil mask for the surface: until EndStencilMask, draws to the surface mark the
+	// pixels they cover in the mask instead of changing any color. The surface gets a stencil attachment
+	// the first time it is masked, and any mask it already had is replaced.
+	//
+	// Masks do not nest: only one mask can be drawn at a time, and calling BeginStencilMask again
+	// before EndStencilMask returns a DeepError. ClearSurface does not clear the mask, use ClearStencilMask.
+	BeginStencilMask(surfaceID SurfaceID) DeepError
+	// Finishes the mask started by BeginStencilMask. Until the mask is cleared or replaced, every draw to the
+	// surface only affects pixels inside the mask when keepInside is true, or outside it when false.
+	EndStencilMask(keepInside bool) DeepError
+	// Removes the surface's mask so draws affect the whole surface again
+	ClearStencilMask(surfaceID SurfaceID) DeepError
+	// Downloads the pixels inside area (or the whole surface if area is empty) from the surface.
+	// The returned image is always top-down as the image package expects, regardless of XRightYUpZAway().
+	ReadSurfacePixels(surfaceID SurfaceID, area IRect2D) (image.RGBA, DeepError)
+
+	// Reports whether batchID names a batch added with AddDrawBatch and not yet deleted
+	IsBatchValid(batchID BatchID) bool
+	// Reports whether shape is still live in its batch: false once it is deleted or the batch is cleared.
+	// Every method taking a BatchShape returns a DeepError for a shape that is not valid, without touching the batch.
+	IsShapeValid(shape BatchShape) bool
 
+	// Reports whether the prototype can be allocated without growing the batch's buffers: its vertices and
+	// indexes each fit in the largest free zone of their buffer or in the unused space at its end.
+	// Allocating a shape that does not fit still succeeds, but grows (and re-uploads) the buffers.
+	CanBatchFit(batchID BatchID, prototype ShapePrototype) (bool, DeepError)
+	// Returns a DeepError without allocating if the prototype does not fit the batch's draw mode (see ValidatePrototype).
+	// A prototype with an IndexCount of 0 takes no space in the index buffer and its vertices are drawn in order.
 	AllocateShapeInBatch(batchID BatchID, prototype ShapePrototype) (BatchShape, DeepError)
+	// Defers the uploads of vertex writes to the batch until the matching EndBatchUpdate, which uploads every
+	// vertex written in between as one contiguous transfer covering the touched range. Calls may be nested,
+	// only the outermost EndBatchUpdate uploads. The shape builders bracket their vertex loops with these.
+	BeginBatchUpdate(batchID BatchID) DeepError
+	EndBatchUpdate(batchID BatchID) DeepError
 	UpdateVertexInShape(shape BatchShape, vertNumber uint32, vertex Vertex) DeepError
+	// Writes consecutive vertices starting at firstVert in a single upload
+	UpdateVerticesInShape(shape BatchShape, firstVert uint32, vertices []Vertex) DeepError
+	// Reads back a vertex previously written to the shape. Attributes the batch does not store are returned as their No* value.
+	GetVertexInShape(shape BatchShape, vertNumber uint32) (Vertex, DeepError)
+	// Reads back the shape's indexes, relative to its first vertex like ShapePrototype.Indexes
+	GetIndexesInShape(shape BatchShape) ([]uint32, DeepError)
+	// Rewrites the shape's indexes in place, relative to its first vertex like ShapePrototype.Indexes, without
+	// reallocating. indexes must have exactly IndexCount entries, so primitives are hidden rather than removed:
+	// repeating one index for all of a triangle's corners (or both ends of a line) makes it draw nothing,
+	// which allows cheap reveal and wipe effects. Shapes allocated without indexes cannot be given any.
+	UpdateShapeIndexes(shape BatchShape, indexes []uint32) DeepError
 	HideShape(shape BatchShape) DeepError
 	ShowShape(shape BatchShape) DeepError
+	// Reports whether the shape is hidden by HideShape or SetBatchVisible
+	IsShapeHidden(shape BatchShape) (bool, DeepError)
 	DeleteShape(shape BatchShape) DeepError
+	// Allocates a shape matching src in the destination batch and copies all of its vertex and index data.
+	// Returns a DeepError if the two batches' VertexFlags do not have SameAttributes.
+	CopyShape(src BatchShape, destBatchID BatchID) (BatchShape, DeepError)
+	// Hides or shows every shape in the batch without deleting them
+	SetBatchVisible(batchID BatchID, visible bool) DeepError
+	// Returns the live shapes in the batch in allocation order. Hidden shapes are included,
+	// deleted shapes are excluded even if their buffer zones have not been reused yet.
+	GetBatchShapes(batchID BatchID) ([]BatchShape, DeepError)
+	GetBatchShapeCount(batchID BatchID) (uint32, DeepError)
+	// Sets the draw order of a shape within its batch. Shapes draw from lowest to highest layer,
+	// shapes sharing a layer draw in allocation order, and all shapes start on layer 0.
+	//
+	// Changing a layer marks the batch's index order dirty: the next DrawBatch re-sorts and re-uploads
+	// the batch's indexes once (O(n log n) in shapes), after which the sorted order is cached and reused
+	// until another layer changes, so layers should not be changed every frame on large batches.
+	SetShapeLayer(shape BatchShape, layer int16) DeepError
+	GetShapeLayer(shape BatchShape) (int16, DeepError)
+	// Sets a model matrix the backend applies to the shape's vertices at draw time (default IdentityMat4),
+	// before the renderer's camera. Unlike rewriting the vertices, the stored vertices are left untouched,
+	// which makes this the cheaper choice for shapes that move every frame. GetVertexInShape, the bounds
+	// helpers and hit testing all see the untransformed vertices.
+	SetShapeTransform(shape BatchShape, transform Mat4) DeepError
+
+	// Depth testing only has an effect on surfaces created with AddDrawSurfaceWithDepth
+	SetRendererDepthTest(rendererID RendererID, enabled bool, writeDepth bool, compare DepthFunc) DeepError
+	// Skips drawing the triangles facing the way mode names (default CullNone), see SetRendererFrontFace
+	SetRendererCullMode(rendererID RendererID, mode CullMode) DeepError
+	// Sets which triangles face the viewer: those whose vertices appear counter-clockwise on the surface
+	// when windingCCW is true (the default), or clockwise when false. Winding is judged after the camera
+	// projection, which follows XRightYUpZAway(), so it matches the front faces of RecalculateNormals.
+	// Meshes wound the other way can be drawn correctly by setting false instead of rewriting their indexes.
+	SetRendererFrontFace(rendererID RendererID, windingCCW bool) DeepError
+	// Marks the surfaces drawn by this renderer as sRGB: colors and texels are converted to linear light before
+	// being interpolated and blended, and back to sRGB when written (see SRGBToLinear). Disabled by default,
+	// which interpolates and blends the sRGB values directly.
+	SetRendererSRGB(rendererID RendererID, enabled bool) DeepError
+	// Uniform values are cached on the renderer and uploaded on the next DrawBatch using it.
+	// Setting a uniform name the renderer's shaders do not declare returns a DeepError.
+	SetRendererUniformFloat(rendererID RendererID, name string, value float32) DeepError
+	SetRendererUniformVec2(rendererID RendererID, name string, value Vec2) DeepError
+	SetRendererUniformVec3(rendererID RendererID, name string, value Vec3) DeepError
+	SetRendererUniformVec4(rendererID RendererID, name string, value Vec4) DeepError
+	SetRendererUniformMat4(rendererID RendererID, name string, value Mat4) DeepError
+
+	// Sets the camera used when DrawBatch draws with this renderer, uploading the result of
+	// Camera.ViewProjection(). Renderers without a camera set (or with NoCam flags) draw in surface space.
+	SetCamera2D(rendererID RendererID, center Vec2, zoom float32, rotationDeg float32) DeepError
+	SetCamera3D(rendererID RendererID, position Vec3, target Vec3, up Vec3, fovDeg float32, near float32, far float32) DeepError
+	// Sets how 2D positions map onto the surfaces this renderer draws to, for both NoCam and Cam2D
+	// (see CoordinateConvention). Defaults to YUpOriginCenter.
+	SetRendererCoordinateConvention(rendererID RendererID, convention CoordinateConvention) DeepError
+	GetCamera(rendererID RendererID) (Camera, DeepError)
 
+	AddInstancedShape(batchID BatchID, prototype ShapePrototype, maxInstances uint32) (InstancedShape, DeepError)
+	SetInstanceTransform(shape InstancedShape, index uint32, transform Mat4, color ColorFA) DeepError
+	DrawInstanced(shape InstancedShape, surfaceID SurfaceID, rendererID RendererID, instanceCount uint32) DeepError
+
+	// Marks an area of the surface, in pixels, whose contents changed and must be redrawn by the batch's next draw
+	MarkBatchDirtyRegion(batchID BatchID, region IRect2D) DeepError
+	// Draws the batch's visible shapes onto the surface. Drawing only renders into the surface, nothing
+	// drawn to a window surface is shown until PresentSurface is called.
+	//
+	// When regions were marked with MarkBatchDirtyRegion and forceRedraw is false, only pixels inside the
+	// bounding box of the marked regions are redrawn and shapes outside it may be skipped entirely, then
+	// the marked regions are cleared. With forceRedraw, or when no regions are marked, the whole batch
+	// is drawn and any marked regions are discarded.
+	//
+	// Redrawn pixels are blended over what the surface already holds, so each dirty region should
+	// normally be reset with ClearSurfaceArea (and any batches beneath it redrawn) before drawing.
 	DrawBatch(batchID BatchID, surfaceID SurfaceID, rendererID RendererID, forceRedraw bool) DeepError
+	// Same as DrawBatch, but only pixels inside clip are affected. The clip is intersected with the
+	// surface bounds (see IntersectIRect2D) and with the dirty regions as described in DrawBatch,
+	// and the previous scissor state is restored afterwards.
+	DrawBatchClipped(batchID BatchID, surfaceID SurfaceID, rendererID RendererID, clip IRect2D, forceRedraw bool) DeepError
+	// Frees every shape in the batch, invalidating their BatchShape handles, so the batch can be refilled from empty
 	ClearBatch(batchID BatchID) DeepError
+	// Writes NullVert to every vertex of every shape in the batch without freeing anything. Unlike ClearBatch,
+	// the shapes keep their zones, layers and visibility and their BatchShape handles stay valid, so a pool of
+	// shapes can be blanked each frame and only the ones needed rewritten with the Update* methods.
+	ResetBatchShapes(batchID BatchID) DeepError
 }
 
 var _ GraphicsInterface = (*GraphicsProvider)(nil)
 
 type GraphicsProvider struct {
 	GraphicsInterface
+	// When true, polygon and circle helpers reuse cached unit circle points for each side count
+	// instead of recomputing sin/cos for every point on every update. The cache is shared by all
+	// providers and holds the first polygonCacheLimit distinct side counts used, other side counts are
+	// computed as if the cache were off. Off by default.
+	EnablePolygonCache bool
+	// Set by EnableDebugDraw
+	debug *debugDraw
+	// Batches created by GetOrCreateBatch
+	sharedBatches map[sharedBatchKey]BatchID
 }
 
 var ninf = math.NInf32()
@@ -43,6 +253,33 @@ var NoNorm = Vec3{ninf, ninf, ninf}
 
 var NullVert = Vertex{Pos: NoVert, Norm: NoNorm, UV: NoUV, Color: NoColor, Extra: NoExtra}
 
+// The limits reported by GetGraphicsLimits
+type GraphicsLimits struct {
+	// Largest width or height of a texture or draw surface, in texels
+	MaxTextureSize uint32
+	// Number of textures a renderer's shaders can sample at once, the range of Texture.TexUnit
+	MaxTextureUnits uint32
+	// Number of vertex attributes a renderer's shaders can take
+	MaxVertexAttributes uint32
+	// Most coverage samples per pixel AddDrawSurfaceMSAA accepts
+	MaxSamples uint32
+}
+
+// Reports whether a texture or surface of size can be created
+func (l GraphicsLimits) FitsTexture(size IVec2) bool {
+	return size.X() > 0 && size.Y() > 0 && uint32(size.X()) <= l.MaxTextureSize && uint32(size.Y()) <= l.MaxTextureSize
+}
+
+// Returns the largest sample count AddDrawSurfaceMSAA accepts that is no more than samples, at least 1
+func (g GraphicsProvider) ClampMSAASamples(samples uint32) uint32 {
+	samples = math.Min(samples, g.GetGraphicsLimits().MaxSamples)
+	valid := uint32(1)
+	for valid*2 <= samples && valid < 16 {
+		valid *= 2
+	}
+	return valid
+}
+
 // Describes the types of vertex attributes present on a draw batch or renderer.
 //
 // Zero value defaults to: 2D Positions + 16 bit indexes + Traingle draw mode + No texture + No Color + No Extra data blocks + No Camera
@@ -99,10 +336,12 @@ const (
 	NoNorms   VertexFlags = 0     // No vertex Normals
 	Norms     VertexFlags = 16384 // Includes Vertex normals
 	NormsMask VertexFlags = 16384 // Mask for checking if uses vertex normals
-	_un4      VertexFlags = 32768
+	NoInst    VertexFlags = 0     // Every shape has its own vertices
+	Instanced VertexFlags = 32768 // Shapes share vertices and draw once per instance with a per-instance transform and color
+	InstMask  VertexFlags = 32768 // Mask for checking if uses instancing
 
-	VertexAttributeMask  VertexFlags = PosMask | ColMask | IdxMask | TexMask | ExMask | NormsMask // Mask describing layout of vertex attributes and indexes
-	UniformAttributeMask VertexFlags = CamMask | DrawMask                                         // Mask decribing rendering uniforms and draw mode
+	VertexAttributeMask  VertexFlags = PosMask | ColMask | IdxMask | TexMask | ExMask | NormsMask | InstMask // Mask describing layout of vertex attributes and indexes
+	UniformAttributeMask VertexFlags = CamMask | DrawMask                                                    // Mask decribing rendering uniforms and draw mode
 )
 
 func (vf VertexFlags) SameAttributes(other VertexFlags) bool {
@@ -206,6 +445,13 @@ func (vf VertexFlags) Stride() uint32 {
 	return sum
 }
 
+func (vf VertexFlags) InstanceStride() uint32 {
+	if vf&InstMask == Instanced {
+		return 80 // Mat4 transform + ColorFA color
+	}
+	return 0
+}
+
 type BatchID uint8
 type RendererID uint8
 type SurfaceID uint8
@@ -215,10 +461,56 @@ type Vertex struct {
 	Pos   Vec3
 	Norm  Vec3
 	UV    Vec2
-	Color ColorFA
+	Color ColorFA // sRGB encoded, like every color the shape helpers take (see SetRendererSRGB)
 	Extra VertExtra
 }
 
+// Describes how a batch's output color is combined with the color already on the draw surface.
+// DrawBatch binds the batch's blend mode before drawing; new batches default to BlendAlpha
+// (BlendPremultipliedAlpha for premultiplied textures).
+//
+// Vertex colors in every color format (Col8 through ColFA) are expected to be straight (non-premultiplied)
+// alpha for all modes except BlendPremultipliedAlpha, where RGB must already be multiplied by alpha.
+// Formats without an alpha channel (Col24, Col48, ColF) and NoCol batches are treated as fully opaque,
+// so BlendAlpha behaves like BlendNone for them.
+//
+// Textures added with Premultiply set are sampled premultiplied, so batches using them must draw with
+// BlendPremultipliedAlpha, which AddDrawBatch selects for them. Drawing a premultiplied texture with
+// BlendAlpha would multiply by alpha twice, darkening its translucent edges.
+type BlendMode uint8
+
+const (
+	BlendAlpha              BlendMode = iota // src * srcAlpha + dst * (1 - srcAlpha)
+	BlendNone                                // src replaces dst
+	BlendAdditive                            // src * srcAlpha + dst
+	BlendMultiply                            // src * dst
+	BlendPremultipliedAlpha                  // src + dst * (1 - srcAlpha)
+)
+
+// Comparison used by depth testing to decide if a new fragment replaces the one already on the surface
+type DepthFunc uint8
+
+const (
+	DepthLess DepthFunc = iota
+	DepthLessEqual
+	DepthEqual
+	DepthNotEqual
+	DepthGreater
+	DepthGreaterEqual
+	DepthAlways
+	DepthNever
+)
+
+// Which triangles a renderer skips, by the way they face the viewer (see SetRendererFrontFace).
+// Lines and pixels are never culled.
+type CullMode uint8
+
+const (
+	CullNone  CullMode = iota // Draws every triangle
+	CullBack                  // Skips triangles facing away from the viewer
+	CullFront                 // Skips triangles facing the viewer
+)
+
 type ShaderType uint8
 
 const (
@@ -238,13 +530,43 @@ type Texture struct {
 	MipMaps uint32
 	ID      uint32
 	TexUnit uint32
+	// When true the straight alpha Data is premultiplied (see PremultiplyAlpha) once as it is added, so
+	// filtering and blending never mix in the color of transparent texels, which shows as dark halos
+	// around straight alpha sprites. Batches created with the texture start with BlendPremultipliedAlpha,
+	// whose vertex colors must be premultiplied too.
+	Premultiply bool
 }
 
+// Describes a single shader stage.
+//
+// When Compiled is true, Data holds the backend-specific compiled form of the stage
+// and AddRenderer will use it directly instead of compiling Code/File again.
 type Shader struct {
-	SType ShaderType
-	Code  string
-	Data  []byte
-	File  string
+	SType    ShaderType
+	Code     string
+	Data     []byte
+	File     string
+	Compiled bool
+}
+
+// A texture split into regions (in texels) that can be referenced by index
+type TextureAtlas struct {
+	TextureID TextureID
+	Size      IVec2
+	Regions   []Rect2D
+}
+
+// Returns the UV rect of the region at index, or false if there is no such region (or no atlas)
+func (a *TextureAtlas) RegionUV(index uint32) (Rect2D, bool) {
+	if a == nil || index >= uint32(len(a.Regions)) {
+		return Rect2D{}, false
+	}
+	size := Vec2{float32(a.Size.X()), float32(a.Size.Y())}
+	region := a.Regions[index]
+	return Rect2D{
+		Vec2{region[0].X() / size.X(), region[0].Y() / size.Y()},
+		Vec2{region[1].X() / size.X(), region[1].Y() / size.Y()},
+	}, true
 }
 
 type ShapePrototype struct {
@@ -259,6 +581,17 @@ type BatchShape struct {
 	VertexZone  BufferZone
 	IndexCount  uint32
 	VertexCount uint32
+	// Distinguishes shapes allocated at different times in the same zones, so a handle kept after
+	// DeleteShape stays invalid when a new shape reuses its zones
+	Generation uint32
+}
+
+// A shape whose vertices are stored once and drawn up to MaxInstances times,
+// each instance with its own transform and color. Only valid in batches with the Instanced flag.
+type InstancedShape struct {
+	BatchShape
+	InstanceZone BufferZone
+	MaxInstances uint32
 }
 
 func (b BatchShape) IdxLen() uint32 {
@@ -269,6 +602,301 @@ func (b BatchShape) VertLen() uint32 {
 	return b.VertexZone.Len()
 }
 
+/**************
+	SHADERS
+***************/
+
+// Compiles each shader that is not already compiled and caches the result in Shader.Data,
+// so that a failing stage can be reported on its own before any renderer is built.
+//
+// The returned DeepError holds one child per failing shader, each containing the compiler log.
+func (g GraphicsProvider) CompileShaders(shaders []*Shader) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] CompileShaders():")
+	dErr.IsErr = false
+	for _, shader := range shaders {
+		if shader.Compiled {
+			continue
+		}
+		data, err := g.CompileShader(shader)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			continue
+		}
+		shader.Data = data
+		shader.Compiled = true
+	}
+	return dErr
+}
+
+/**************
+	MESHES
+***************/
+
+// Allocates a shape and writes all of its vertices in one upload, for geometry that is fully known up front
+func (g GraphicsProvider) AddShapeWithVertices(batchID BatchID, prototype ShapePrototype, vertices []Vertex) (BatchShape, DeepError) {
+	if uint32(len(vertices)) != prototype.VertCount {
+		return BatchShape{}, utils.NewDeepError("[PolyApp] AddShapeWithVertices(): number of vertices does not match prototype vertex count")
+	}
+	dErr := utils.NewDeepError("[PolyApp] AddShapeWithVertices():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, prototype)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(bSlice, 0, vertices))
+	return bSlice, dErr
+}
+
+/**************
+	VISIBILITY
+***************/
+
+// Hides every shape, continuing past shapes that fail and collecting their errors
+func (g GraphicsProvider) HideShapes(shapes []BatchShape) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] HideShapes():")
+	dErr.IsErr = false
+	for _, shape := range shapes {
+		dErr.AddChildDeepError(g.HideShape(shape))
+	}
+	return dErr
+}
+
+// Shows every shape, continuing past shapes that fail and collecting their errors
+func (g GraphicsProvider) ShowShapes(shapes []BatchShape) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] ShowShapes():")
+	dErr.IsErr = false
+	for _, shape := range shapes {
+		dErr.AddChildDeepError(g.ShowShape(shape))
+	}
+	return dErr
+}
+
+/**************
+	BATCHES
+***************/
+
+// Copies every live shape in src into dest, then clears src so its old shapes are no longer valid.
+// Both batches must have SameAttributes and SameUniforms.
+//
+// The returned map goes from each old shape in src to its new shape in dest.
+func (g GraphicsProvider) MergeBatches(dest BatchID, src BatchID) (map[BatchShape]BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] MergeBatches():")
+	dErr.IsErr = false
+	destFlags, err := g.GetBatchVertexFlags(dest)
+	dErr.AddChildDeepError(err)
+	srcFlags, err := g.GetBatchVertexFlags(src)
+	dErr.AddChildDeepError(err)
+	if dErr.IsErr {
+		return nil, dErr
+	}
+	if !destFlags.SameAttributes(srcFlags) || !destFlags.SameUniforms(srcFlags) {
+		return nil, utils.NewDeepError("[PolyApp] MergeBatches(): batches do not have the same vertex attributes and uniforms")
+	}
+	shapes, err := g.GetBatchShapes(src)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, dErr
+	}
+	mapping := make(map[BatchShape]BatchShape, len(shapes))
+	for _, shape := range shapes {
+		newShape, err := g.CopyShape(shape, dest)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return mapping, dErr
+		}
+		mapping[shape] = newShape
+	}
+	dErr.AddChildDeepError(g.ClearBatch(src))
+	return mapping, dErr
+}
+
+// Returns the largest rect with the content's aspect ratio (width / height) that fits in the window, centered
+// so the remaining space forms equal bars on either side (pillarbox) or above and below (letterbox), for
+// use with SetViewport. Returns an empty rect if either is empty.
+func ComputeLetterbox(windowSize IVec2, contentAspect float32) (viewport IRect2D) {
+	if windowSize.X() <= 0 || windowSize.Y() <= 0 || contentAspect <= 0 {
+		return IRect2D{}
+	}
+	w, h := windowSize.X(), windowSize.Y()
+	if float32(w) > float32(h)*contentAspect {
+		w = int32(math.Round(float32(h) * contentAspect))
+	} else {
+		h = int32(math.Round(float32(w) / contentAspect))
+	}
+	x, y := (windowSize.X()-w)/2, (windowSize.Y()-h)/2
+	return IRect2D{{x, y}, {x + w, y + h}}
+}
+
+type sharedBatchKey struct {
+	flags     VertexFlags
+	textureID TextureID
+}
+
+// Returns the batch GetOrCreateBatch made earlier for flags and textureID, creating it with AddDrawBatch
+// the first time. Flags match when they have SameAttributes and SameUniforms, so every shape added to the
+// batch is drawn the same way. Batches made directly with AddDrawBatch are never returned.
+//
+// The batches are remembered by the provider, so the same GraphicsProvider (such as App.Graphics) should be
+// used for every call: copies made before the first call do not see the batches it creates.
+func (g *GraphicsProvider) GetOrCreateBatch(flags VertexFlags, textureID TextureID) (BatchID, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] GetOrCreateBatch():")
+	dErr.IsErr = false
+	key := sharedBatchKey{flags: flags & (VertexAttributeMask | UniformAttributeMask), textureID: textureID}
+	if batchID, ok := g.sharedBatches[key]; ok {
+		return batchID, dErr
+	}
+	batchID, err := g.AddDrawBatch(flags, textureID, 256)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return batchID, dErr
+	}
+	if g.sharedBatches == nil {
+		g.sharedBatches = map[sharedBatchKey]BatchID{}
+	}
+	g.sharedBatches[key] = batchID
+	return batchID, dErr
+}
+
+/**************
+	BOUNDS
+***************/
+
+// Returns the smallest box containing every vertex position in the shape.
+// Shapes in 2D batches have a Z extent of zero.
+func (g GraphicsProvider) GetShapeBounds(shape BatchShape) (Rect3D, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] GetShapeBounds():")
+	dErr.IsErr = false
+	bounds := Rect3D{}
+	for i := uint32(0); i < shape.VertexCount; i += 1 {
+		vert, err := g.GetVertexInShape(shape, i)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return bounds, dErr
+		}
+		if i == 0 {
+			bounds = Rect3D{vert.Pos, vert.Pos}
+			continue
+		}
+		bounds = expandRect3D(bounds, vert.Pos)
+	}
+	return bounds, dErr
+}
+
+// Returns the smallest box containing every live shape in the batch
+func (g GraphicsProvider) GetBatchBounds(batchID BatchID) (Rect3D, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] GetBatchBounds():")
+	dErr.IsErr = false
+	bounds := Rect3D{}
+	shapes, err := g.GetBatchShapes(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bounds, dErr
+	}
+	for i, shape := range shapes {
+		shapeBounds, err := g.GetShapeBounds(shape)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return bounds, dErr
+		}
+		if i == 0 {
+			bounds = shapeBounds
+			continue
+		}
+		bounds = expandRect3D(expandRect3D(bounds, shapeBounds[0]), shapeBounds[1])
+	}
+	return bounds, dErr
+}
+
+func expandRect3D(rect Rect3D, point Vec3) Rect3D {
+	for i := 0; i < 3; i += 1 {
+		rect[0][i] = math.Min(rect[0][i], point[i])
+		rect[1][i] = math.Max(rect[1][i], point[i])
+	}
+	return rect
+}
+
+/**************
+	HIT TESTING
+***************/
+
+// Reports whether point lies inside any of the shape's triangles, using only their X and Y
+func (g GraphicsProvider) HitTestShape2D(shape BatchShape, point Vec2) (bool, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] HitTestShape2D():")
+	dErr.IsErr = false
+	indexes, err := g.GetIndexesInShape(shape)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return false, dErr
+	}
+	verts := make([]Vec2, shape.VertexCount)
+	for i := range verts {
+		vert, err := g.GetVertexInShape(shape, uint32(i))
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return false, dErr
+		}
+		verts[i] = vert.Pos.AsVec2()
+	}
+	for i := 0; i+2 < len(indexes); i += 3 {
+		if triangleContains(verts[indexes[i]], verts[indexes[i+1]], verts[indexes[i+2]], point) {
+			return true, dErr
+		}
+	}
+	return false, dErr
+}
+
+// Returns every visible shape in the batch containing point, topmost (last drawn) first: shapes on higher
+// layers (see SetShapeLayer) come first, and shapes sharing a layer are ordered last allocated first.
+func (g GraphicsProvider) HitTestBatch2D(batchID BatchID, point Vec2) ([]BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] HitTestBatch2D():")
+	dErr.IsErr = false
+	shapes, err := g.GetBatchShapes(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, dErr
+	}
+	type layeredShape struct {
+		shape BatchShape
+		layer int16
+	}
+	visible := make([]layeredShape, 0, len(shapes))
+	for _, shape := range shapes {
+		hidden, err := g.IsShapeHidden(shape)
+		dErr.AddChildDeepError(err)
+		layer, err := g.GetShapeLayer(shape)
+		dErr.AddChildDeepError(err)
+		if !hidden {
+			visible = append(visible, layeredShape{shape, layer})
+		}
+	}
+	sort.SliceStable(visible, func(i, j int) bool { return visible[i].layer < visible[j].layer })
+	hits := make([]BatchShape, 0)
+	for i := len(visible) - 1; i >= 0; i -= 1 {
+		hit, err := g.HitTestShape2D(visible[i].shape, point)
+		dErr.AddChildDeepError(err)
+		if hit {
+			hits = append(hits, visible[i].shape)
+		}
+	}
+	return hits, dErr
+}
+
+// Reports whether point is inside or on the edge of the triangle. Triangles with no area (such as shapes
+// collapsed onto one point) or with non-finite corners contain nothing.
+func triangleContains(a Vec2, b Vec2, c Vec2, point Vec2) bool {
+	area := float64(b.Sub(a).Cross(c.Sub(a)))
+	if area == 0 || gomath.IsNaN(area) || gomath.IsInf(area, 0) {
+		return false
+	}
+	d1 := b.Sub(a).Cross(point.Sub(a))
+	d2 := c.Sub(b).Cross(point.Sub(b))
+	d3 := a.Sub(c).Cross(point.Sub(c))
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
 /**************
 	LINES
 ***************/
@@ -316,119 +944,407 @@ func (g GraphicsProvider) UpdateLine2D(shape BatchShape, a Vertex, b Vertex, thi
 	return dErr
 }
 
-/**************
-	TRIANGLES
-***************/
+// How the corners of a thick outline are joined
+type JoinType uint8
 
-func (g GraphicsProvider) AddTriangle2D(batchID BatchID, a Vertex, b Vertex, c Vertex) (BatchShape, DeepError) {
-	dErr := utils.NewDeepError("[PolyApp] AddTriangle2D():")
+const (
+	// Extends both edges to meet at a point, falling back to JoinBevel when the point would be
+	// more than miterLimit times the half thickness from the corner
+	JoinMiter JoinType = iota
+	// Cuts the corner off with a straight edge
+	JoinBevel
+	// Rounds the corner with an arc of roundJoinPoints vertices
+	JoinRound
+)
+
+const (
+	miterLimit      = 4
+	roundJoinPoints = 8
+)
+
+// Returns the number of vertices each side of the outline has at every corner for join
+func joinPoints(join JoinType) uint32 {
+	if join == JoinRound {
+		return roundJoinPoints
+	}
+	return 2
+}
+
+// Creates a thick outline of the closed loop through points (the last point joins back to the first),
+// centered on the points with every corner joined by join. Each vertex takes its color, UV and extra from
+// the point it belongs to.
+//
+// At sharp concave corners where the inner edges would cross over the neighbouring segments, the inner side
+// of the corner folds back to the point itself instead, so the outline overlaps slightly there rather than
+// growing spikes. Consecutive points must not be equal.
+func (g GraphicsProvider) AddClosedPolylineOutline2D(batchID BatchID, points []Vertex, thickness float32, join JoinType) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddClosedPolylineOutline2D():")
 	dErr.IsErr = false
+	if len(points) < 3 {
+		return BatchShape{}, utils.NewDeepError("[PolyApp] AddClosedPolylineOutline2D(): a closed polyline needs at least 3 points")
+	}
+	k, n := joinPoints(join), uint32(len(points))
+	idx := make([]uint32, 0, 6*k*n)
+	for i := uint32(0); i < n; i += 1 {
+		left, right := i*2*k, i*2*k+k
+		for j := uint32(0); j < k-1; j += 1 {
+			idx = append(idx, left+j, left+j+1, right+j+1, left+j, right+j+1, right+j)
+		}
+		nextLeft, nextRight := ((i+1)%n)*2*k, ((i+1)%n)*2*k+k
+		idx = append(idx, left+k-1, nextLeft, nextRight, left+k-1, nextRight, right+k-1)
+	}
 	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
-		VertCount:  3,
-		IndexCount: 3,
-		Indexes:    []uint32{0, 1, 2},
+		VertCount:  2 * k * n,
+		IndexCount: 6 * k * n,
+		Indexes:    idx,
 	})
 	if err.IsErr {
 		dErr.AddChildDeepError(err)
 		return bSlice, dErr
 	}
-	a.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
-	b.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
-	c.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
-	err.AddChildDeepError(g.UpdateTriangle2D(bSlice, a, b, c))
-	return bSlice, err
+	dErr.AddChildDeepError(g.UpdateClosedPolylineOutline2D(bSlice, points, thickness, join))
+	return bSlice, dErr
 }
 
-func (g GraphicsProvider) UpdateTriangle2D(shape BatchShape, a Vertex, b Vertex, c Vertex) DeepError {
-	if shape.VertexCount != 3 || shape.IndexCount != 3 {
-		return utils.NewDeepError("[PolyApp] UpdateTriangle2D(): batch slice provided does not have required dimensions for a triangle")
+func (g GraphicsProvider) UpdateClosedPolylineOutline2D(shape BatchShape, points []Vertex, thickness float32, join JoinType) DeepError {
+	k, n := joinPoints(join), uint32(len(points))
+	if n < 3 || shape.VertexCount != 2*k*n || shape.IndexCount != 6*k*n {
+		return utils.NewDeepError("[PolyApp] UpdateClosedPolylineOutline2D(): batch shape provided does not have required dimensions for a closed polyline of specified points and join")
 	}
-	dErr := utils.NewDeepError("[PolyApp] UpdateTriangle2D():")
+	dErr := utils.NewDeepError("[PolyApp] UpdateClosedPolylineOutline2D():")
 	dErr.IsErr = false
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 0, a))
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 1, b))
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 2, c))
+	half := thickness / 2
+	verts := make([]Vertex, 2*k*n)
+	for i := uint32(0); i < n; i += 1 {
+		p := points[i].Pos.AsVec2()
+		dIn := p.Sub(points[(i+n-1)%n].Pos.AsVec2())
+		dOut := points[(i+1)%n].Pos.AsVec2().Sub(p)
+		lenIn, lenOut := dIn.Len(), dOut.Len()
+		if lenIn == 0 || lenOut == 0 {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] UpdateClosedPolylineOutline2D(): point %d is equal to its neighbour", i))
+		}
+		dIn, dOut = dIn.Scale(1/lenIn), dOut.Scale(1/lenOut)
+		// signed turn from the incoming to the outgoing direction (positive turns left), which also turns the left normals
+		turn := math.ACosDeg(math.Clamp(-1, dIn.Dot(dOut), 1))
+		outside := float32(1)
+		if dIn.Cross(dOut) > 0 {
+			outside = -1
+		} else {
+			turn = -turn
+		}
+		_, nIn := dIn.Perp()
+		nOut := rotateVec2(nIn, turn)
+		miter := rotateVec2(nIn, turn/2)
+		cosHalf := math.CosDeg(turn / 2)
+		outer := make([]Vec2, k)
+		inner := make([]Vec2, k)
+		switch {
+		case join == JoinRound:
+			for j := uint32(0); j < k; j += 1 {
+				outer[j] = rotateVec2(nIn, turn*float32(j)/float32(k-1)).Scale(half * outside)
+			}
+		case join == JoinMiter && cosHalf > 1/float32(miterLimit):
+			for j := range outer {
+				outer[j] = miter.Scale(half * outside / cosHalf)
+			}
+		default:
+			outer[0], outer[1] = nIn.Scale(half*outside), nOut.Scale(half*outside)
+		}
+		if cosHalf > 0 && half*math.Abs(math.TanDeg(turn/2)) <= math.Min(lenIn, lenOut) {
+			for j := range inner {
+				inner[j] = miter.Scale(-half * outside / cosHalf)
+			}
+		} else {
+			inner[0], inner[k-1] = nIn.Scale(-half*outside), nOut.Scale(-half*outside)
+		}
+		v := points[i]
+		v.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+		left, right := inner, outer
+		if outside > 0 {
+			left, right = outer, inner
+		}
+		for j := uint32(0); j < k; j += 1 {
+			v.Pos = p.Add(left[j]).AsVec3()
+			verts[i*2*k+j] = v
+			v.Pos = p.Add(right[j]).AsVec3()
+			verts[i*2*k+k+j] = v
+		}
+	}
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, verts))
 	return dErr
 }
 
-/**************
-	POLYGONS
-***************/
+// Turns v counter-clockwise (X toward Y) by degrees, Vec2.Rotate returns its input unchanged
+func rotateVec2(v Vec2, degrees float32) Vec2 {
+	cos, sin := math.CosDeg(degrees), math.SinDeg(degrees)
+	return Vec2{v.X()*cos - v.Y()*sin, v.X()*sin + v.Y()*cos}
+}
 
-func (g GraphicsProvider) AddRegularPolygon2D(batchID BatchID, center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) (BatchShape, DeepError) {
-	dErr := utils.NewDeepError("[PolyApp] AddRegularPolygon2D():")
+// Creates a connected run of 1 pixel lines through points, for batches using the Lines draw mode
+func (g GraphicsProvider) AddLineStrip2D(batchID BatchID, points []Vertex) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddLineStrip2D():")
 	dErr.IsErr = false
-	iCount := 3 * sides
-	vCount := sides + 1
-	idx := make([]uint32, iCount)
-	_ = idx[iCount-1]
-	for i, v := uint32(0), uint32(1); i < iCount; i, v = i+3, v+1 {
-		idx[i] = 0
-		idx[i+1] = v
-		idx[i+2] = v + 1
+	if len(points) < 2 {
+		return BatchShape{}, utils.NewDeepError("[PolyApp] AddLineStrip2D(): a line strip needs at least 2 points")
+	}
+	err := g.checkDrawMode(batchID, Lines)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return BatchShape{}, dErr
+	}
+	lineCount := uint32(len(points)) - 1
+	idx := make([]uint32, 2*lineCount)
+	for i, v := uint32(0), uint32(0); v < lineCount; i, v = i+2, v+1 {
+		idx[i] = v
+		idx[i+1] = v + 1
 	}
-	idx[iCount-1] = 1
 	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
-		VertCount:  vCount,
-		IndexCount: iCount,
+		VertCount:  uint32(len(points)),
+		IndexCount: 2 * lineCount,
 		Indexes:    idx,
 	})
 	if err.IsErr {
 		dErr.AddChildDeepError(err)
 		return bSlice, dErr
 	}
-	dErr.AddChildDeepError(g.UpdateRegularPolygon2D(bSlice, center, sides, radius, shapeRotation, uvRadius, uvRotation))
+	dErr.AddChildDeepError(g.UpdateLineStrip2D(bSlice, points))
 	return bSlice, dErr
 }
 
-func (g GraphicsProvider) UpdateRegularPolygon2D(shape BatchShape, center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) DeepError {
-	if shape.VertexCount != sides+1 || shape.IndexCount != sides*3 {
-		return utils.NewDeepError("[PolyApp] UpdateRegularPolygon2D(): batch shape provided does not have required dimensions for a polygon of specified sides")
+func (g GraphicsProvider) UpdateLineStrip2D(shape BatchShape, points []Vertex) DeepError {
+	if len(points) < 2 || shape.VertexCount != uint32(len(points)) || shape.IndexCount != 2*(uint32(len(points))-1) {
+		return utils.NewDeepError("[PolyApp] UpdateLineStrip2D(): batch shape provided does not have required dimensions for a line strip of specified points")
 	}
-	dErr := utils.NewDeepError("[PolyApp] UpdateRegularPolygon2D():")
+	dErr := utils.NewDeepError("[PolyApp] UpdateLineStrip2D():")
 	dErr.IsErr = false
-	center.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
-	points := geom.PointsOnCircle(shapeRotation*math.DEG_TO_RAD, radius, center.Pos.AsVec2(), sides)
-	uvs := geom.PointsOnCircle(uvRotation*math.DEG_TO_RAD, uvRadius, center.UV, sides)
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 0, center))
-	for i := uint32(0); i < uint32(len(points)); i += 1 {
-		center.Pos = points[i].AsVec3()
-		center.UV = uvs[i]
-		dErr.AddChildDeepError(g.UpdateVertexInShape(shape, i+1, center))
-	}
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, g.flatVertices2D(points)))
 	return dErr
 }
 
-func (g GraphicsProvider) AddRegularPolygonRing2D(batchID BatchID, center Vertex, sides uint32, innerRadius float32, outerRadius float32, shapeRotation float32, uvInnerRadius float32, uvOuterRadius float32, uvRotation float32) (BatchShape, DeepError) {
-	dErr := utils.NewDeepError("[PolyApp] AddRegularPolygonRing2D():")
+// Creates a single pixel for each of points, for batches using the Pixels draw mode
+func (g GraphicsProvider) AddPointCloud2D(batchID BatchID, points []Vertex) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddPointCloud2D():")
 	dErr.IsErr = false
-	iCount := 6 * sides
-	vCount := 2 * sides
-	idx := make([]uint32, iCount)
-	_ = idx[iCount-1]
-	for i, v := uint32(0), uint32(0); i < iCount; i, v = i+6, v+2 {
-		idx[i] = v
-		idx[i+1] = v + 1
-		idx[i+2] = v + 2
-		idx[i+3] = v
-		idx[i+4] = v + 3
-		idx[i+5] = v + 2
+	if len(points) == 0 {
+		return BatchShape{}, utils.NewDeepError("[PolyApp] AddPointCloud2D(): a point cloud needs at least 1 point")
+	}
+	err := g.checkDrawMode(batchID, Pixels)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return BatchShape{}, dErr
 	}
-	idx[iCount-2] = 1
-	idx[iCount-1] = 0
 	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
-		VertCount:  vCount,
-		IndexCount: iCount,
-		Indexes:    idx,
+		VertCount: uint32(len(points)),
 	})
 	if err.IsErr {
 		dErr.AddChildDeepError(err)
-		return bSlice, err
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdatePointCloud2D(bSlice, points))
+	return bSlice, dErr
+}
+
+func (g GraphicsProvider) UpdatePointCloud2D(shape BatchShape, points []Vertex) DeepError {
+	if shape.VertexCount != uint32(len(points)) || shape.IndexCount != 0 {
+		return utils.NewDeepError("[PolyApp] UpdatePointCloud2D(): batch shape provided does not have required dimensions for a point cloud of specified points")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdatePointCloud2D():")
+	dErr.IsErr = false
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, g.flatVertices2D(points)))
+	return dErr
+}
+
+// Returns a copy of vertices with every normal facing the viewer
+func (g GraphicsProvider) flatVertices2D(vertices []Vertex) []Vertex {
+	flat := make([]Vertex, len(vertices))
+	copy(flat, vertices)
+	for i := range flat {
+		flat[i].Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	}
+	return flat
+}
+
+// Returns a DeepError naming the mismatch if the prototype cannot be drawn by the batch: Tris batches need
+// a multiple of 3 indexes, Lines batches a multiple of 2, Pixels batches draw every vertex and take no indexes,
+// and every index must refer to one of the prototype's vertices. A Tris or Lines prototype without indexes
+// draws its vertices in order (every 3 a triangle or every 2 a line), so needs a multiple of 3 or 2 vertices.
+func (g GraphicsProvider) ValidatePrototype(batchID BatchID, prototype ShapePrototype) DeepError {
+	flags, err := g.GetBatchVertexFlags(batchID)
+	if err.IsErr {
+		return err
+	}
+	return validatePrototype("ValidatePrototype", flags, prototype)
+}
+
+func validatePrototype(method string, flags VertexFlags, prototype ShapePrototype) DeepError {
+	if uint32(len(prototype.Indexes)) != prototype.IndexCount {
+		return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): prototype has %d indexes but an IndexCount of %d", method, len(prototype.Indexes), prototype.IndexCount))
+	}
+	switch flags & DrawMask {
+	case Tris:
+		if prototype.IndexCount%3 != 0 {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): Tris batch needs a multiple of 3 indexes, prototype has %d", method, prototype.IndexCount))
+		}
+		if prototype.IndexCount == 0 && prototype.VertCount%3 != 0 {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): Tris batch needs a multiple of 3 vertices when drawn without indexes, prototype has %d", method, prototype.VertCount))
+		}
+	case Lines:
+		if prototype.IndexCount%2 != 0 {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): Lines batch needs a multiple of 2 indexes, prototype has %d", method, prototype.IndexCount))
+		}
+		if prototype.IndexCount == 0 && prototype.VertCount%2 != 0 {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): Lines batch needs a multiple of 2 vertices when drawn without indexes, prototype has %d", method, prototype.VertCount))
+		}
+	case Pixels:
+		if prototype.IndexCount != 0 {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): Pixels batch draws every vertex and takes no indexes, prototype has %d", method, prototype.IndexCount))
+		}
+	}
+	for i, index := range prototype.Indexes {
+		if index >= prototype.VertCount {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] %s(): index %d refers to vertex %d but prototype only has %d vertices", method, i, index, prototype.VertCount))
+		}
+	}
+	dErr := utils.NewDeepError("")
+	dErr.IsErr = false
+	return dErr
+}
+
+// Returns the bytes the prototype's vertices and indexes take in the buffers of a batch with flags.
+// Indexes are always uint32.
+func EstimateShapeSize(prototype ShapePrototype, flags VertexFlags) (vertexBytes uint32, indexBytes uint32) {
+	return prototype.VertCount * flags.Stride(), prototype.IndexCount * 4
+}
+
+// Returns a DeepError if the batch does not use the given draw mode
+func (g GraphicsProvider) checkDrawMode(batchID BatchID, mode VertexFlags) DeepError {
+	flags, err := g.GetBatchVertexFlags(batchID)
+	if err.IsErr {
+		return err
+	}
+	if flags&DrawMask != mode {
+		return utils.NewDeepError("[PolyApp] checkDrawMode(): batch draw mode does not match the primitive")
+	}
+	return err
+}
+
+/**************
+	TRIANGLES
+***************/
+
+func (g GraphicsProvider) AddTriangle2D(batchID BatchID, a Vertex, b Vertex, c Vertex) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddTriangle2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
+		VertCount:  3,
+		IndexCount: 3,
+		Indexes:    []uint32{0, 1, 2},
+	})
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	a.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	b.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	c.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	err.AddChildDeepError(g.UpdateTriangle2D(bSlice, a, b, c))
+	return bSlice, err
+}
+
+func (g GraphicsProvider) UpdateTriangle2D(shape BatchShape, a Vertex, b Vertex, c Vertex) DeepError {
+	if shape.VertexCount != 3 || shape.IndexCount != 3 {
+		return utils.NewDeepError("[PolyApp] UpdateTriangle2D(): batch slice provided does not have required dimensions for a triangle")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateTriangle2D():")
+	dErr.IsErr = false
+	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 0, a))
+	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 1, b))
+	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 2, c))
+	return dErr
+}
+
+/**************
+	POLYGONS
+***************/
+
+func (g GraphicsProvider) AddRegularPolygon2D(batchID BatchID, center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddRegularPolygon2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, regularPolygonPrototype(sides))
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateRegularPolygon2D(bSlice, center, sides, radius, shapeRotation, uvRadius, uvRotation))
+	return bSlice, dErr
+}
+
+func regularPolygonPrototype(sides uint32) ShapePrototype {
+	iCount := 3 * sides
+	vCount := sides + 1
+	idx := make([]uint32, iCount)
+	_ = idx[iCount-1]
+	for i, v := uint32(0), uint32(1); i < iCount; i, v = i+3, v+1 {
+		idx[i] = 0
+		idx[i+1] = v
+		idx[i+2] = v + 1
+	}
+	idx[iCount-1] = 1
+	return ShapePrototype{
+		VertCount:  vCount,
+		IndexCount: iCount,
+		Indexes:    idx,
+	}
+}
+
+func (g GraphicsProvider) UpdateRegularPolygon2D(shape BatchShape, center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) DeepError {
+	if shape.VertexCount != sides+1 || shape.IndexCount != sides*3 {
+		return utils.NewDeepError("[PolyApp] UpdateRegularPolygon2D(): batch shape provided does not have required dimensions for a polygon of specified sides")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateRegularPolygon2D():")
+	dErr.IsErr = false
+	center.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	points := g.pointsOnCircle(shapeRotation, radius, center.Pos.AsVec2(), sides)
+	uvs := g.pointsOnCircle(uvRotation, uvRadius, center.UV, sides)
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, regularPolygonVertices(center, points, uvs)))
+	return dErr
+}
+
+func (g GraphicsProvider) AddRegularPolygonRing2D(batchID BatchID, center Vertex, sides uint32, innerRadius float32, outerRadius float32, shapeRotation float32, uvInnerRadius float32, uvOuterRadius float32, uvRotation float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddRegularPolygonRing2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, regularPolygonRingPrototype(sides))
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, err
 	}
 	dErr.AddChildDeepError(g.UpdateRegularPolygonRing2D(bSlice, center, sides, innerRadius, outerRadius, shapeRotation, uvInnerRadius, uvOuterRadius, uvRotation))
 	return bSlice, err
 }
 
+func regularPolygonRingPrototype(sides uint32) ShapePrototype {
+	iCount := 6 * sides
+	vCount := 2 * sides
+	idx := make([]uint32, iCount)
+	_ = idx[iCount-1]
+	for i, v := uint32(0), uint32(0); i < iCount; i, v = i+6, v+2 {
+		idx[i] = v
+		idx[i+1] = v + 1
+		idx[i+2] = v + 2
+		idx[i+3] = v
+		idx[i+4] = v + 3
+		idx[i+5] = v + 2
+	}
+	idx[iCount-4] = 0
+	idx[iCount-2] = 1
+	idx[iCount-1] = 0
+	return ShapePrototype{
+		VertCount:  vCount,
+		IndexCount: iCount,
+		Indexes:    idx,
+	}
+}
+
 func (g GraphicsProvider) UpdateRegularPolygonRing2D(shape BatchShape, center Vertex, sides uint32, innerRadius float32, outerRadius float32, shapeRotation float32, uvInnerRadius float32, uvOuterRadius float32, uvRotation float32) DeepError {
 	if shape.VertexCount != sides*2 || shape.IndexCount != sides*6 {
 		return utils.NewDeepError("[PolyApp] UpdateRegularPolygonRing2D(): batch shape provided does not have required dimensions for a polygon ring of specified sides")
@@ -436,16 +1352,64 @@ func (g GraphicsProvider) UpdateRegularPolygonRing2D(shape BatchShape, center Ve
 	dErr := utils.NewDeepError("[PolyApp] UpdateRegularPolygonRing2D():")
 	dErr.IsErr = false
 	center.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
-	uvs := geom.PointsOnRing(uvRotation*math.DEG_TO_RAD, uvInnerRadius, uvOuterRadius, center.UV, sides)
-	points := geom.PointsOnRing(shapeRotation*math.DEG_TO_RAD, innerRadius, outerRadius, center.Pos.AsVec2(), sides)
-	for i := uint32(0); i < uint32(len(points)); i += 1 {
-		center.Pos = points[i].AsVec3()
-		center.UV = uvs[i]
-		dErr.AddChildDeepError(g.UpdateVertexInShape(shape, i, center))
-	}
+	uvs := g.pointsOnRing(uvRotation, uvInnerRadius, uvOuterRadius, center.UV, sides)
+	points := g.pointsOnRing(shapeRotation, innerRadius, outerRadius, center.Pos.AsVec2(), sides)
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, ringVertices(center, points, uvs)))
 	return dErr
 }
 
+// Most side counts the polygon cache holds, so apps generating many distinct side counts (such as
+// CircleScreenSides while zooming) can't grow it without bound
+const polygonCacheLimit = 64
+
+var polygonCache = map[uint32][]Vec2{}
+var polygonCacheLock = sync.RWMutex{}
+
+// Returns the points of a regular polygon with the given number of sides on a circle of radius 1
+// centered on the origin, starting at angle 0. The points are only kept while the cache has room.
+func unitPolygonPoints(sides uint32) []Vec2 {
+	polygonCacheLock.RLock()
+	points, ok := polygonCache[sides]
+	polygonCacheLock.RUnlock()
+	if ok {
+		return points
+	}
+	points = geom.PointsOnCircle(0, float32(1), ZeroVec2, sides)
+	polygonCacheLock.Lock()
+	if len(polygonCache) < polygonCacheLimit {
+		polygonCache[sides] = points
+	}
+	polygonCacheLock.Unlock()
+	return points
+}
+
+func (g GraphicsProvider) pointsOnCircle(rotationDeg float32, radius float32, center Vec2, sides uint32) []Vec2 {
+	if !g.EnablePolygonCache {
+		return geom.PointsOnCircle(rotationDeg*math.DEG_TO_RAD, radius, center, sides)
+	}
+	unit := unitPolygonPoints(sides)
+	cos, sin := math.CosDeg(rotationDeg)*radius, math.SinDeg(rotationDeg)*radius
+	points := make([]Vec2, len(unit))
+	for i, u := range unit {
+		points[i] = Vec2{u.X()*cos - u.Y()*sin + center.X(), u.X()*sin + u.Y()*cos + center.Y()}
+	}
+	return points
+}
+
+func (g GraphicsProvider) pointsOnRing(rotationDeg float32, innerRadius float32, outerRadius float32, center Vec2, sides uint32) []Vec2 {
+	if !g.EnablePolygonCache {
+		return geom.PointsOnRing(rotationDeg*math.DEG_TO_RAD, innerRadius, outerRadius, center, sides)
+	}
+	inner := g.pointsOnCircle(rotationDeg, innerRadius, center, sides)
+	outer := g.pointsOnCircle(rotationDeg, outerRadius, center, sides)
+	points := make([]Vec2, 2*len(inner))
+	for i := range inner {
+		points[2*i] = inner[i]
+		points[2*i+1] = outer[i]
+	}
+	return points
+}
+
 /**************
 	CIRCLES
 ***************/
@@ -481,6 +1445,217 @@ func (g GraphicsProvider) UpdateCircleRingAutoPoints2D(shape BatchShape, center
 	return dErr
 }
 
+// Most sides CircleScreenSides returns, however large the circle is on the surface
+const MaxCircleScreenSides uint32 = 1024
+
+// Returns the side count giving a circle of worldRadius at center roughly pixelsPerSegment pixels per side
+// on the surface, as seen through the renderer's current camera. Never returns less than 3 or more than
+// MaxCircleScreenSides. pixelsPerSegment must be greater than 0.
+func (g GraphicsProvider) CircleScreenSides(center Vec3, worldRadius float32, rendererID RendererID, surfaceID SurfaceID, pixelsPerSegment float32) (uint32, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] CircleScreenSides():")
+	dErr.IsErr = false
+	if !(pixelsPerSegment > 0) {
+		dErr.AddChildError(fmt.Errorf("pixelsPerSegment must be greater than 0, got %v", pixelsPerSegment))
+		return 3, dErr
+	}
+	screenCenter, err := g.WorldToScreen(rendererID, surfaceID, center)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return 3, dErr
+	}
+	// the larger of the projected X and Y radii, so stretched or rotated projections are not under-tessellated
+	screenRadius := float32(0)
+	for _, offset := range []Vec3{{worldRadius, 0, 0}, {0, worldRadius, 0}} {
+		edge, err := g.WorldToScreen(rendererID, surfaceID, center.Add(offset))
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return 3, dErr
+		}
+		screenRadius = math.Max(screenRadius, edge.Sub(screenCenter).Len())
+	}
+	// compared as floats so huge or non-finite radii can't overflow the conversion
+	sides := math.Ciel(geom.Circumference(screenRadius) / pixelsPerSegment)
+	if !(sides >= 3) {
+		return 3, dErr
+	}
+	if sides >= float32(MaxCircleScreenSides) {
+		return MaxCircleScreenSides, dErr
+	}
+	return uint32(sides), dErr
+}
+
+// Same as AddCircleAutoPoints2D, but the side count is picked from the circle's size on the surface
+// (see CircleScreenSides) instead of its world size, so zoomed out circles use fewer sides.
+// The side count is fixed once added, re-add the circle when the zoom changes enough to matter.
+func (g GraphicsProvider) AddCircleScreenAdaptive2D(batchID BatchID, center Vertex, worldRadius float32, rendererID RendererID, surfaceID SurfaceID, pixelsPerSegment float32, uvRadius float32, uvRotation float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddCircleScreenAdaptive2D():")
+	dErr.IsErr = false
+	sides, err := g.CircleScreenSides(center.Pos, worldRadius, rendererID, surfaceID, pixelsPerSegment)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return BatchShape{}, dErr
+	}
+	bs, err := g.AddRegularPolygon2D(batchID, center, sides, worldRadius, 0, uvRadius, uvRotation)
+	dErr.AddChildDeepError(err)
+	return bs, dErr
+}
+
+/**************
+	ANTIALIASING
+***************/
+
+// Same as AddRegularPolygon2D, but with a feathered edge: the polygon is surrounded by a band
+// feather units wide (measured across each edge) that fades from center.Color to fully transparent,
+// centered on the polygon's edge. This smooths the edge without MSAA.
+//
+// The feather is drawn with vertex alpha, so the batch must store an alpha color channel (Col8, Col16, Col32, Col64 or ColFA)
+// and use BlendAlpha. The shape costs 2*sides+1 vertices and 9*sides indexes, compared to
+// sides+1 vertices and 3*sides indexes without antialiasing.
+func (g GraphicsProvider) AddRegularPolygonAA2D(batchID BatchID, center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32, feather float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddRegularPolygonAA2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, antiAliasedPolygonPrototype(sides))
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateRegularPolygonAA2D(bSlice, center, sides, radius, shapeRotation, uvRadius, uvRotation, feather))
+	return bSlice, dErr
+}
+
+// Vertex 0 is the center, vertices 1 to sides are the opaque inner ring and
+// vertices sides+1 to 2*sides are the transparent outer ring
+func antiAliasedPolygonPrototype(sides uint32) ShapePrototype {
+	fan := regularPolygonPrototype(sides)
+	idx := make([]uint32, 9*sides)
+	copy(idx, fan.Indexes)
+	for s, i := uint32(0), 3*sides; s < sides; s, i = s+1, i+6 {
+		next := (s + 1) % sides
+		inner, innerNext := 1+s, 1+next
+		outer, outerNext := 1+sides+s, 1+sides+next
+		idx[i] = inner
+		idx[i+1] = outer
+		idx[i+2] = outerNext
+		idx[i+3] = inner
+		idx[i+4] = outerNext
+		idx[i+5] = innerNext
+	}
+	return ShapePrototype{
+		VertCount:  2*sides + 1,
+		IndexCount: 9 * sides,
+		Indexes:    idx,
+	}
+}
+
+func (g GraphicsProvider) UpdateRegularPolygonAA2D(shape BatchShape, center Vertex, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32, feather float32) DeepError {
+	if shape.VertexCount != 2*sides+1 || shape.IndexCount != sides*9 {
+		return utils.NewDeepError("[PolyApp] UpdateRegularPolygonAA2D(): batch shape provided does not have required dimensions for an antialiased polygon of specified sides")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateRegularPolygonAA2D():")
+	dErr.IsErr = false
+	// the corners sit further out than the edges, so scale the feather to be feather wide across each edge
+	halfFeather := feather / 2 / math.CosDeg(180/float32(sides))
+	innerRadius := math.Max(radius-halfFeather, 0)
+	outerRadius := radius + halfFeather
+	uvInnerRadius, uvOuterRadius := uvRadius, uvRadius
+	if radius > 0 {
+		uvInnerRadius = uvRadius * innerRadius / radius
+		uvOuterRadius = uvRadius * outerRadius / radius
+	}
+	center.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	inner := g.pointsOnCircle(shapeRotation, innerRadius, center.Pos.AsVec2(), sides)
+	outer := g.pointsOnCircle(shapeRotation, outerRadius, center.Pos.AsVec2(), sides)
+	uvInner := g.pointsOnCircle(uvRotation, uvInnerRadius, center.UV, sides)
+	uvOuter := g.pointsOnCircle(uvRotation, uvOuterRadius, center.UV, sides)
+	vertices := make([]Vertex, 2*sides+1)
+	vertices[0] = center
+	for i := uint32(0); i < sides; i += 1 {
+		vertices[1+i] = center
+		vertices[1+i].Pos = inner[i].AsVec3()
+		vertices[1+i].UV = uvInner[i]
+		vertices[1+sides+i] = center
+		vertices[1+sides+i].Pos = outer[i].AsVec3()
+		vertices[1+sides+i].UV = uvOuter[i]
+		vertices[1+sides+i].Color[3] = 0
+	}
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, vertices))
+	return dErr
+}
+
+// Same as AddCircleAutoPoints2D, but with a feathered edge as described in AddRegularPolygonAA2D
+func (g GraphicsProvider) AddCircleAutoPointsAA2D(batchID BatchID, center Vertex, resolution float32, radius float32, uvRadius float32, uvRotation float32, feather float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddCircleAutoPointsAA2D():")
+	dErr.IsErr = false
+	sides := uint32(math.Ciel(geom.Circumference(radius) / resolution))
+	bs, err := g.AddRegularPolygonAA2D(batchID, center, sides, radius, 0, uvRadius, uvRotation, feather)
+	dErr.AddChildDeepError(err)
+	return bs, dErr
+}
+func (g GraphicsProvider) UpdateCircleAutoPointsAA2D(shape BatchShape, center Vertex, resolution float32, radius float32, uvRadius float32, uvRotation float32, feather float32) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] UpdateCircleAutoPointsAA2D():")
+	dErr.IsErr = false
+	sides := uint32(math.Ciel(geom.Circumference(radius) / resolution))
+	dErr.AddChildDeepError(g.UpdateRegularPolygonAA2D(shape, center, sides, radius, 0, uvRadius, uvRotation, feather))
+	return dErr
+}
+
+/**************
+	CAPSULES
+***************/
+
+// Returns the segment count of each end of a capsule, derived from resolution like AddCircleAutoPoints2D
+func capsuleSegments(radius float32, resolution float32) uint32 {
+	return math.Max(2, uint32(math.Ciel(geom.Circumference(radius)/2/resolution)))
+}
+
+// Creates a stadium shape: the rectangle between centers a and b, radius wide on each side, capped by a
+// semicircle around each center. Emitted as a single convex fan around the midpoint of a and b, so equal
+// centers draw a full circle. Vertices are untextured (UV {0, 0}).
+func (g GraphicsProvider) AddCapsule2D(batchID BatchID, a Vec2, b Vec2, radius float32, resolution float32, color ColorFA, extra VertExtra) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddCapsule2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, regularPolygonPrototype(2*capsuleSegments(radius, resolution)+2))
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateCapsule2D(bSlice, a, b, radius, resolution, color, extra))
+	return bSlice, dErr
+}
+
+func (g GraphicsProvider) UpdateCapsule2D(shape BatchShape, a Vec2, b Vec2, radius float32, resolution float32, color ColorFA, extra VertExtra) DeepError {
+	segments := capsuleSegments(radius, resolution)
+	sides := 2*segments + 2
+	if shape.VertexCount != sides+1 || shape.IndexCount != sides*3 {
+		return utils.NewDeepError("[PolyApp] UpdateCapsule2D(): batch shape provided does not have required dimensions for a capsule of specified radius and resolution")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateCapsule2D():")
+	dErr.IsErr = false
+	dir := Vec2{1, 0}
+	if length := b.Sub(a).Len(); length > 0 {
+		dir = b.Sub(a).Scale(1 / length)
+	}
+	_, left := dir.Perp()
+	center := Vertex{
+		Pos:   a.Add(b).Scale(0.5).AsVec3(),
+		Norm:  Vec3{0, 0, -g.XRightYUpZAway()[2]},
+		Color: color,
+		Extra: extra,
+	}
+	points := make([]Vec2, 0, sides)
+	for _, end := range []struct {
+		center Vec2
+		start  float32
+	}{{b, -90}, {a, 90}} {
+		for j := uint32(0); j <= segments; j += 1 {
+			angle := end.start + 180*float32(j)/float32(segments)
+			points = append(points, end.center.Add(dir.Scale(radius*math.CosDeg(angle))).Add(left.Scale(radius*math.SinDeg(angle))))
+		}
+	}
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, regularPolygonVertices(center, points, make([]Vec2, sides))))
+	return dErr
+}
+
 /**************
 	RECTANGLES
 ***************/
@@ -488,11 +1663,7 @@ func (g GraphicsProvider) UpdateCircleRingAutoPoints2D(shape BatchShape, center
 func (g GraphicsProvider) AddQuad2D(batchID BatchID, quad Quad2D, color ColorFA, uvQuad Quad2D, extra VertExtra) (BatchShape, DeepError) {
 	dErr := utils.NewDeepError("[PolyApp] AddQuad2D():")
 	dErr.IsErr = false
-	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
-		VertCount:  4,
-		IndexCount: 6,
-		Indexes:    []uint32{0, 1, 2, 2, 3, 0},
-	})
+	bSlice, err := g.AllocateShapeInBatch(batchID, quadPrototype())
 	if err.IsErr {
 		dErr.AddChildDeepError(err)
 		return bSlice, dErr
@@ -500,31 +1671,23 @@ func (g GraphicsProvider) AddQuad2D(batchID BatchID, quad Quad2D, color ColorFA,
 	dErr.AddChildDeepError(g.UpdateQuad2D(bSlice, quad, color, uvQuad, extra))
 	return bSlice, dErr
 }
+func quadPrototype() ShapePrototype {
+	return ShapePrototype{
+		VertCount:  4,
+		IndexCount: 6,
+		Indexes:    []uint32{0, 1, 2, 2, 3, 0},
+	}
+}
 func (g GraphicsProvider) UpdateQuad2D(shape BatchShape, quad Quad2D, color ColorFA, uvQuad Quad2D, extra VertExtra) DeepError {
 	if shape.VertexCount != 4 || shape.IndexCount != 6 {
 		return utils.NewDeepError("[PolyApp] UpdateQuad2D(): batch shape provided does not have required dimensions for a quad")
 	}
 	dErr := utils.NewDeepError("[PolyApp] UpdateQuad2D():")
 	dErr.IsErr = false
-	v := Vertex{
-		Pos:   quad.A().AsVec3(),
-		Norm:  Vec3{0, 0, -g.XRightYUpZAway()[2]},
-		UV:    uvQuad.A(),
-		Color: color,
-		Extra: extra,
-	}
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 0, v))
-	v.Pos = quad.B().AsVec3()
-	v.UV = uvQuad.B()
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 1, v))
-	v.Pos = quad.C().AsVec3()
-	v.UV = uvQuad.C()
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 2, v))
-	v.Pos = quad.D().AsVec3()
-	v.UV = uvQuad.D()
-	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 3, v))
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, quadVertices(quad, color, uvQuad, extra, Vec3{0, 0, -g.XRightYUpZAway()[2]})))
 	return dErr
 }
+
 func (g GraphicsProvider) AddRect2D(batchID BatchID, rect Rect2D, color ColorFA, uvRect Rect2D, extra VertExtra) (BatchShape, DeepError) {
 	dErr := utils.NewDeepError("[PolyApp] AddRect2D():")
 	dErr.IsErr = false
@@ -613,3 +1776,510 @@ func (g GraphicsProvider) UpdateRectOutline2D(shape BatchShape, rect Rect2D, thi
 	dErr.AddChildDeepError(g.UpdateQuadOutline2D(shape, innerQuad, outerQuad, color, uvInnerQuad, uvOuterQuad, extra))
 	return dErr
 }
+
+/**************
+	NINE SLICE
+***************/
+
+// Draws rect with a texture region split into a 3x3 grid, so the corners keep their size while the
+// edges and center stretch to fill rect. Emitted as a single 16 vertex shape.
+//
+// texRect is the region of the texture in texels and texSize the full texture size used to convert texels to UVs.
+// border holds the insets in texels: border[0] for the Min X and Min Y sides (left/top when Y is down)
+// and border[1] for the Max X and Max Y sides (right/bottom when Y is down). Corners are drawn
+// one unit per texel.
+func (g GraphicsProvider) AddNineSlice2D(batchID BatchID, rect Rect2D, texRect Rect2D, texSize IVec2, border Rect2D, color ColorFA, extra VertExtra) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddNineSlice2D():")
+	dErr.IsErr = false
+	if err := validateNineSliceBorder(texRect, border); err.IsErr {
+		dErr.AddChildDeepError(err)
+		return BatchShape{}, dErr
+	}
+	idx := make([]uint32, 0, 54)
+	for row := uint32(0); row < 3; row += 1 {
+		for col := uint32(0); col < 3; col += 1 {
+			v := row*4 + col
+			idx = append(idx, v, v+1, v+5, v+5, v+4, v)
+		}
+	}
+	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
+		VertCount:  16,
+		IndexCount: 54,
+		Indexes:    idx,
+	})
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateNineSlice2D(bSlice, rect, texRect, texSize, border, color, extra))
+	return bSlice, dErr
+}
+
+func (g GraphicsProvider) UpdateNineSlice2D(shape BatchShape, rect Rect2D, texRect Rect2D, texSize IVec2, border Rect2D, color ColorFA, extra VertExtra) DeepError {
+	if shape.VertexCount != 16 || shape.IndexCount != 54 {
+		return utils.NewDeepError("[PolyApp] UpdateNineSlice2D(): batch shape provided does not have required dimensions for a nine slice")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateNineSlice2D():")
+	dErr.IsErr = false
+	if err := validateNineSliceBorder(texRect, border); err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	size := Vec2{float32(texSize.X()), float32(texSize.Y())}
+	xs := [4]float32{rect[0].X(), rect[0].X() + border[0].X(), rect[1].X() - border[1].X(), rect[1].X()}
+	ys := [4]float32{rect[0].Y(), rect[0].Y() + border[0].Y(), rect[1].Y() - border[1].Y(), rect[1].Y()}
+	us := [4]float32{texRect[0].X(), texRect[0].X() + border[0].X(), texRect[1].X() - border[1].X(), texRect[1].X()}
+	vs := [4]float32{texRect[0].Y(), texRect[0].Y() + border[0].Y(), texRect[1].Y() - border[1].Y(), texRect[1].Y()}
+	v := Vertex{
+		Norm:  Vec3{0, 0, -g.XRightYUpZAway()[2]},
+		Color: color,
+		Extra: extra,
+	}
+	dErr.AddChildDeepError(g.BeginBatchUpdate(shape.BatchID))
+	for row := uint32(0); row < 4; row += 1 {
+		for col := uint32(0); col < 4; col += 1 {
+			v.Pos = Vec3{xs[col], ys[row], 0}
+			v.UV = Vec2{us[col] / size.X(), vs[row] / size.Y()}
+			dErr.AddChildDeepError(g.UpdateVertexInShape(shape, row*4+col, v))
+		}
+	}
+	dErr.AddChildDeepError(g.EndBatchUpdate(shape.BatchID))
+	return dErr
+}
+
+func validateNineSliceBorder(texRect Rect2D, border Rect2D) DeepError {
+	if border[0].X() < 0 || border[0].Y() < 0 || border[1].X() < 0 || border[1].Y() < 0 {
+		return utils.NewDeepError("[PolyApp] nine slice border insets cannot be negative")
+	}
+	if border[0].X()+border[1].X() > texRect.W() || border[0].Y()+border[1].Y() > texRect.H() {
+		return utils.NewDeepError("[PolyApp] nine slice border insets exceed the texture region dimensions")
+	}
+	dErr := utils.NewDeepError("")
+	dErr.IsErr = false
+	return dErr
+}
+
+/**************
+	TILE MAPS
+***************/
+
+// Atlas index marking a tile map cell as empty
+const EmptyTile uint32 = 0xFFFFFFFF
+
+// A grid of tiles drawn from a TextureAtlas as one fixed-size shape with 4 vertices per cell.
+// Empty cells are collapsed to zero-area quads.
+type TileMap2D struct {
+	BatchShape
+	Origin   Vec2
+	TileSize Vec2
+	Cols     uint32
+	Rows     uint32
+	Atlas    *TextureAtlas
+}
+
+// Creates a tile map where tileIndices holds the atlas index of each cell, row by row
+func (g GraphicsProvider) AddTileMap2D(batchID BatchID, origin Vec2, tileSize Vec2, cols uint32, rows uint32, atlas *TextureAtlas, tileIndices []uint32) (TileMap2D, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddTileMap2D():")
+	dErr.IsErr = false
+	tileMap := TileMap2D{Origin: origin, TileSize: tileSize, Cols: cols, Rows: rows, Atlas: atlas}
+	if atlas == nil {
+		return tileMap, utils.NewDeepError("[PolyApp] AddTileMap2D(): atlas is nil")
+	}
+	cells := cols * rows
+	if uint32(len(tileIndices)) != cells {
+		return tileMap, utils.NewDeepError("[PolyApp] AddTileMap2D(): number of tile indices does not match cols * rows")
+	}
+	idx := make([]uint32, 0, cells*6)
+	for v := uint32(0); v < cells*4; v += 4 {
+		idx = append(idx, v, v+1, v+2, v+2, v+3, v)
+	}
+	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
+		VertCount:  cells * 4,
+		IndexCount: cells * 6,
+		Indexes:    idx,
+	})
+	tileMap.BatchShape = bSlice
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return tileMap, dErr
+	}
+	dErr.AddChildDeepError(g.BeginBatchUpdate(batchID))
+	for i, atlasIndex := range tileIndices {
+		dErr.AddChildDeepError(g.SetTile(tileMap, uint32(i)%cols, uint32(i)/cols, atlasIndex))
+	}
+	dErr.AddChildDeepError(g.EndBatchUpdate(batchID))
+	return tileMap, dErr
+}
+
+// Changes the atlas region drawn in a single cell, or empties it when atlasIndex is EmptyTile
+func (g GraphicsProvider) SetTile(tileMap TileMap2D, col uint32, row uint32, atlasIndex uint32) DeepError {
+	if col >= tileMap.Cols || row >= tileMap.Rows {
+		return utils.NewDeepError("[PolyApp] SetTile(): cell is outside the tile map")
+	}
+	if tileMap.VertexCount != tileMap.Cols*tileMap.Rows*4 || tileMap.IndexCount != tileMap.Cols*tileMap.Rows*6 {
+		return utils.NewDeepError("[PolyApp] SetTile(): batch shape provided does not have required dimensions for the tile map")
+	}
+	dErr := utils.NewDeepError("[PolyApp] SetTile():")
+	dErr.IsErr = false
+	min := tileMap.Origin.Add(Vec2{float32(col) * tileMap.TileSize.X(), float32(row) * tileMap.TileSize.Y()})
+	quad := Rect2D{min, min}.Quad()
+	uvQuad := quad
+	if atlasIndex != EmptyTile {
+		if tileMap.Atlas == nil {
+			return utils.NewDeepError("[PolyApp] SetTile(): tile map has no atlas")
+		}
+		uvRect, ok := tileMap.Atlas.RegionUV(atlasIndex)
+		if !ok {
+			return utils.NewDeepError("[PolyApp] SetTile(): atlas index is outside the atlas regions")
+		}
+		quad = Rect2D{min, min.Add(tileMap.TileSize)}.Quad()
+		uvQuad = uvRect.Quad()
+	}
+	first := (row*tileMap.Cols + col) * 4
+	v := Vertex{
+		Norm:  Vec3{0, 0, -g.XRightYUpZAway()[2]},
+		Color: ColorFA{1, 1, 1, 1},
+	}
+	for i := uint32(0); i < 4; i += 1 {
+		v.Pos = quad[i].AsVec3()
+		v.UV = uvQuad[i]
+		dErr.AddChildDeepError(g.UpdateVertexInShape(tileMap.BatchShape, first+i, v))
+	}
+	return dErr
+}
+
+/**************
+	SPRITES
+***************/
+
+type SpriteAnimMode uint8
+
+const (
+	SpriteLoop     SpriteAnimMode = iota // Restarts from the first frame after the last
+	SpritePingPong                       // Plays back and forth, without repeating the first and last frames
+)
+
+// Steps through UV frames of a sprite sheet (see TextureAtlas.RegionUV) at a fixed rate
+type SpriteAnimation struct {
+	Frames        []Rect2D
+	FrameDuration float32
+	Mode          SpriteAnimMode
+	Frame         uint32
+	elapsed       float32
+	backwards     bool
+}
+
+// Moves the animation forward by dt seconds, skipping as many frames as dt covers
+func (a *SpriteAnimation) Advance(dt float32) {
+	count := uint32(len(a.Frames))
+	if count < 2 || a.FrameDuration <= 0 {
+		return
+	}
+	a.elapsed += dt
+	for a.elapsed >= a.FrameDuration {
+		a.elapsed -= a.FrameDuration
+		switch {
+		case a.Mode == SpritePingPong && a.backwards:
+			a.Frame -= 1
+			a.backwards = a.Frame != 0
+		case a.Mode == SpritePingPong:
+			a.Frame += 1
+			a.backwards = a.Frame == count-1
+		default:
+			a.Frame = (a.Frame + 1) % count
+		}
+	}
+}
+
+// Returns the UV rect of the current frame, or an empty rect if there are no frames
+func (a *SpriteAnimation) CurrentUV() Rect2D {
+	if len(a.Frames) == 0 {
+		return Rect2D{}
+	}
+	return a.Frames[a.Frame%uint32(len(a.Frames))]
+}
+
+// Same as AddRect2D, using the animation's current frame as the UV rect
+func (g GraphicsProvider) AddAnimatedSprite2D(batchID BatchID, rect Rect2D, anim *SpriteAnimation, color ColorFA) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddAnimatedSprite2D():")
+	dErr.IsErr = false
+	bs, err := g.AddRect2D(batchID, rect, color, anim.CurrentUV(), VertExtra{})
+	dErr.AddChildDeepError(err)
+	return bs, dErr
+}
+
+// Rewrites the sprite with the animation's current frame, call it after anim.Advance()
+func (g GraphicsProvider) UpdateAnimatedSprite2D(shape BatchShape, rect Rect2D, anim *SpriteAnimation, color ColorFA) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] UpdateAnimatedSprite2D():")
+	dErr.IsErr = false
+	dErr.AddChildDeepError(g.UpdateRect2D(shape, rect, color, anim.CurrentUV(), VertExtra{}))
+	return dErr
+}
+
+// Draws the texRegion of the batch's texture (a UV rect, such as from TextureAtlas.RegionUV) stretched over dest,
+// mirrored horizontally and/or vertically by flipX and flipY, and rotated by rotationDeg about the center of dest
+func (g GraphicsProvider) AddSprite2D(batchID BatchID, dest Rect2D, texRegion Rect2D, color ColorFA, flipX bool, flipY bool, rotationDeg float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddSprite2D():")
+	dErr.IsErr = false
+	quad, uvQuad := spriteQuads(dest, texRegion, flipX, flipY, rotationDeg)
+	bs, err := g.AddQuad2D(batchID, quad, color, uvQuad, VertExtra{})
+	dErr.AddChildDeepError(err)
+	return bs, dErr
+}
+
+func (g GraphicsProvider) UpdateSprite2D(shape BatchShape, dest Rect2D, texRegion Rect2D, color ColorFA, flipX bool, flipY bool, rotationDeg float32) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] UpdateSprite2D():")
+	dErr.IsErr = false
+	quad, uvQuad := spriteQuads(dest, texRegion, flipX, flipY, rotationDeg)
+	dErr.AddChildDeepError(g.UpdateQuad2D(shape, quad, color, uvQuad, VertExtra{}))
+	return dErr
+}
+
+func spriteQuads(dest Rect2D, texRegion Rect2D, flipX bool, flipY bool, rotationDeg float32) (Quad2D, Quad2D) {
+	quad, uvQuad := dest.Quad(), texRegion.Quad()
+	// corners run min, (max X, min Y), max, (min X, max Y)
+	if flipX {
+		uvQuad[0], uvQuad[1], uvQuad[2], uvQuad[3] = uvQuad[1], uvQuad[0], uvQuad[3], uvQuad[2]
+	}
+	if flipY {
+		uvQuad[0], uvQuad[1], uvQuad[2], uvQuad[3] = uvQuad[3], uvQuad[2], uvQuad[1], uvQuad[0]
+	}
+	if rotationDeg != 0 {
+		center := quad[0].Add(quad[2]).Scale(0.5)
+		for i := range quad {
+			quad[i] = rotateVec2(quad[i].Sub(center), rotationDeg).Add(center)
+		}
+	}
+	return quad, uvQuad
+}
+
+// One sprite for DrawSpritesImmediate, drawn like AddSprite2D without flipping
+type SpriteDraw struct {
+	Dest      Rect2D
+	TexRegion Rect2D
+	Color     ColorFA
+	Rotation  float32 // degrees about the center of Dest
+}
+
+// Draws every sprite with the texture in one draw call, in order, without keeping any shapes: the sprites are
+// written as a single shape into a temporary batch, drawn, and the batch deleted again. Meant for sprites
+// that change every frame, such as particles, where tracking AddSprite2D handles costs more than rebuilding.
+// rendererID should take Pos2D|HasTex|ColFA vertices. The texture is passed here rather than per sprite
+// because a batch samples a single texture, so sprites from different textures need separate calls.
+func (g GraphicsProvider) DrawSpritesImmediate(surfaceID SurfaceID, rendererID RendererID, textureID TextureID, sprites []SpriteDraw) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] DrawSpritesImmediate():")
+	dErr.IsErr = false
+	if len(sprites) == 0 {
+		return dErr
+	}
+	prototype := ShapePrototype{
+		VertCount:  uint32(len(sprites)) * 4,
+		IndexCount: uint32(len(sprites)) * 6,
+		Indexes:    make([]uint32, 0, len(sprites)*6),
+	}
+	vertices := make([]Vertex, 0, len(sprites)*4)
+	normal := Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	quadIndexes := quadPrototype().Indexes
+	for i, sprite := range sprites {
+		quad, uvQuad := spriteQuads(sprite.Dest, sprite.TexRegion, false, false, sprite.Rotation)
+		vertices = append(vertices, quadVertices(quad, sprite.Color, uvQuad, NoExtra, normal)...)
+		for _, index := range quadIndexes {
+			prototype.Indexes = append(prototype.Indexes, uint32(i)*4+index)
+		}
+	}
+	batchID, err := g.AddDrawBatch(Pos2D|HasTex|ColFA, textureID, prototype.VertCount)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	_, err = g.AddShapeWithVertices(batchID, prototype, vertices)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+	} else {
+		dErr.AddChildDeepError(g.DrawBatch(batchID, surfaceID, rendererID, true))
+	}
+	dErr.AddChildDeepError(g.DeleteBatch(batchID))
+	return dErr
+}
+
+/**************
+	GRADIENTS
+***************/
+
+// Returns a color for each point, blending from startColor to endColor along the direction
+// given by angleDeg, where the rearmost point gets startColor and the foremost gets endColor
+func LinearGradient(points []Vec2, angleDeg float32, startColor ColorFA, endColor ColorFA) []ColorFA {
+	colors := make([]ColorFA, len(points))
+	if len(points) == 0 {
+		return colors
+	}
+	dir := Vec2{math.CosDeg(angleDeg), math.SinDeg(angleDeg)}
+	dists := make([]float32, len(points))
+	minDist, maxDist := points[0].Dot(dir), points[0].Dot(dir)
+	for i, p := range points {
+		dists[i] = p.Dot(dir)
+		minDist = math.Min(minDist, dists[i])
+		maxDist = math.Max(maxDist, dists[i])
+	}
+	span := maxDist - minDist
+	for i, d := range dists {
+		ratio := float32(0)
+		if span > 0 {
+			ratio = (d - minDist) / span
+		}
+		colors[i] = startColor.BlendWithAlpha(ratio, endColor)
+	}
+	return colors
+}
+
+// Same as AddQuad2D, but each corner (A, B, C, D) has its own color
+func (g GraphicsProvider) AddGradientQuad2D(batchID BatchID, quad Quad2D, cornerColors [4]ColorFA, uvQuad Quad2D, extra VertExtra) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddGradientQuad2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, quadPrototype())
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateGradientQuad2D(bSlice, quad, cornerColors, uvQuad, extra))
+	return bSlice, dErr
+}
+func (g GraphicsProvider) UpdateGradientQuad2D(shape BatchShape, quad Quad2D, cornerColors [4]ColorFA, uvQuad Quad2D, extra VertExtra) DeepError {
+	if shape.VertexCount != 4 || shape.IndexCount != 6 {
+		return utils.NewDeepError("[PolyApp] UpdateGradientQuad2D(): batch shape provided does not have required dimensions for a quad")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateGradientQuad2D():")
+	dErr.IsErr = false
+	v := Vertex{
+		Norm:  Vec3{0, 0, -g.XRightYUpZAway()[2]},
+		Extra: extra,
+	}
+	dErr.AddChildDeepError(g.BeginBatchUpdate(shape.BatchID))
+	for i := uint32(0); i < 4; i += 1 {
+		v.Pos = quad[i].AsVec3()
+		v.UV = uvQuad[i]
+		v.Color = cornerColors[i]
+		dErr.AddChildDeepError(g.UpdateVertexInShape(shape, i, v))
+	}
+	dErr.AddChildDeepError(g.EndBatchUpdate(shape.BatchID))
+	return dErr
+}
+
+// Same as AddRegularPolygon2D, but blends radially from center.Color at the center to edgeColor at the corners
+func (g GraphicsProvider) AddGradientRegularPolygon2D(batchID BatchID, center Vertex, edgeColor ColorFA, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] AddGradientRegularPolygon2D():")
+	dErr.IsErr = false
+	bSlice, err := g.AllocateShapeInBatch(batchID, regularPolygonPrototype(sides))
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.UpdateGradientRegularPolygon2D(bSlice, center, edgeColor, sides, radius, shapeRotation, uvRadius, uvRotation))
+	return bSlice, dErr
+}
+
+func (g GraphicsProvider) UpdateGradientRegularPolygon2D(shape BatchShape, center Vertex, edgeColor ColorFA, sides uint32, radius float32, shapeRotation float32, uvRadius float32, uvRotation float32) DeepError {
+	if shape.VertexCount != sides+1 || shape.IndexCount != sides*3 {
+		return utils.NewDeepError("[PolyApp] UpdateGradientRegularPolygon2D(): batch shape provided does not have required dimensions for a polygon of specified sides")
+	}
+	dErr := utils.NewDeepError("[PolyApp] UpdateGradientRegularPolygon2D():")
+	dErr.IsErr = false
+	center.Norm = Vec3{0, 0, -g.XRightYUpZAway()[2]}
+	points := g.pointsOnCircle(shapeRotation, radius, center.Pos.AsVec2(), sides)
+	uvs := g.pointsOnCircle(uvRotation, uvRadius, center.UV, sides)
+	dErr.AddChildDeepError(g.BeginBatchUpdate(shape.BatchID))
+	dErr.AddChildDeepError(g.UpdateVertexInShape(shape, 0, center))
+	center.Color = edgeColor
+	for i := uint32(0); i < uint32(len(points)); i += 1 {
+		center.Pos = points[i].AsVec3()
+		center.UV = uvs[i]
+		dErr.AddChildDeepError(g.UpdateVertexInShape(shape, i+1, center))
+	}
+	dErr.AddChildDeepError(g.EndBatchUpdate(shape.BatchID))
+	return dErr
+}
+
+/**************
+	SCREENSHOTS
+***************/
+
+// Reads the whole surface and writes it to fileName through file, encoded as imgType (see EncodeImage)
+func (g GraphicsProvider) SaveSurfaceImage(surfaceID SurfaceID, file FileProvider, fileName string, imgType ImageType) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] SaveSurfaceImage():")
+	dErr.IsErr = false
+	img, err := g.ReadSurfacePixels(surfaceID, IRect2D{})
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	nrgba := image.NewNRGBA(img.Rect)
+	draw.Draw(nrgba, nrgba.Rect, &img, img.Rect.Min, draw.Src)
+	data, err := EncodeImage(nrgba.Pix, IVec2{int32(img.Rect.Dx()), int32(img.Rect.Dy())}, imgType)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	dErr.AddChildError(file.SaveFileBytes(fileName, data))
+	return dErr
+}
+
+func (g GraphicsProvider) SaveSurfacePNG(surfaceID SurfaceID, file FileProvider, fileName string) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] SaveSurfacePNG():")
+	dErr.IsErr = false
+	dErr.AddChildDeepError(g.SaveSurfaceImage(surfaceID, file, fileName, ImgPNG))
+	return dErr
+}
+
+/**************
+	BLITTING
+***************/
+
+// Draws the srcUV area of the texture (UV {0, 0} is its top-left) into destRect of the surface, in pixels from
+// the surface's top-left, alpha blended over what the surface holds. The quad is drawn in surface space
+// regardless of XRightYUpZAway(), so rendererID should take Pos2D|HasTex vertices and have no camera set.
+// destRect stays in surface pixels when the surface has a viewport (see SetViewport), but only the part of
+// it inside the viewport is drawn.
+//
+// Each blit adds a batch for its quad and deletes it after drawing, so nothing is kept between blits.
+func (g GraphicsProvider) BlitTexture(textureID TextureID, destSurface SurfaceID, destRect IRect2D, srcUV Rect2D, rendererID RendererID) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] BlitTexture():")
+	dErr.IsErr = false
+	viewport, err := g.GetViewport(destSurface)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	// surface space runs -1 to 1 across the viewport with its top at 1, whatever XRightYUpZAway() is
+	toClip := func(p IVec2) Vec2 {
+		local := p.Sub(viewport[0])
+		return Vec2{2*float32(local.X())/float32(viewport.W()) - 1, 1 - 2*float32(local.Y())/float32(viewport.H())}
+	}
+	rect := Rect2D{toClip(destRect[0]), toClip(destRect[1])}
+	batchID, err := g.AddDrawBatch(Pos2D|HasTex, textureID, 4)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	_, err = g.AddRect2D(batchID, rect, ColorFA{1, 1, 1, 1}, srcUV, NoExtra)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+	} else {
+		dErr.AddChildDeepError(g.DrawBatch(batchID, destSurface, rendererID, true))
+	}
+	dErr.AddChildDeepError(g.DeleteBatch(batchID))
+	return dErr
+}
+
+// Draws the whole texture over the surface's viewport, which is the whole surface unless SetViewport
+// was used, see BlitTexture
+func (g GraphicsProvider) DrawFullscreenTexture(textureID TextureID, destSurface SurfaceID, rendererID RendererID) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] DrawFullscreenTexture():")
+	dErr.IsErr = false
+	viewport, err := g.GetViewport(destSurface)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return dErr
+	}
+	dErr.AddChildDeepError(g.BlitTexture(textureID, destSurface, viewport, Rect2D{{0, 0}, {1, 1}}, rendererID))
+	return dErr
+}
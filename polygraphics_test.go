@@ -0,0 +1,321 @@
+package polyapp
+
+import (
+	gomath "math"
+	"testing"
+)
+
+func newTestNull(t testing.TB) (GraphicsProvider, *NullGraphics) {
+	t.Helper()
+	n := NewNullGraphics(testAxes)
+	return GraphicsProvider{GraphicsInterface: n}, n
+}
+
+func addTestBatch(t testing.TB, g GraphicsProvider, flags VertexFlags) BatchID {
+	t.Helper()
+	batchID, err := g.AddDrawBatch(flags, 0, 64)
+	mustOk(t, err)
+	return batchID
+}
+
+func addTestRect(t testing.TB, g GraphicsProvider, batchID BatchID, rect Rect2D) BatchShape {
+	t.Helper()
+	shape, err := g.AddRect2D(batchID, rect, ColorFA{1, 1, 1, 1}, Rect2D{}, NoExtra)
+	mustOk(t, err)
+	return shape
+}
+
+func TestHitTestShape2D(t *testing.T) {
+	g, _ := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	shape := addTestRect(t, g, batchID, Rect2D{{0, 0}, {10, 10}})
+	for _, tt := range []struct {
+		point Vec2
+		want  bool
+	}{
+		{Vec2{5, 5}, true},
+		{Vec2{0, 0}, true},
+		{Vec2{10, 5}, true},
+		{Vec2{11, 5}, false},
+		{Vec2{100, 100}, false},
+	} {
+		hit, err := g.HitTestShape2D(shape, tt.point)
+		mustOk(t, err)
+		if hit != tt.want {
+			t.Errorf("HitTestShape2D(%v) = %v, want %v", tt.point, hit, tt.want)
+		}
+	}
+}
+
+func TestHitTestIgnoresCollapsedShapes(t *testing.T) {
+	far := Vec2{100, 100}
+	t.Run("empty tile map cells", func(t *testing.T) {
+		g, _ := newTestNull(t)
+		batchID := addTestBatch(t, g, Pos2D|HasTex|ColFA)
+		atlas := &TextureAtlas{Size: IVec2{16, 16}, Regions: []Rect2D{{{0, 0}, {16, 16}}}}
+		tileMap, err := g.AddTileMap2D(batchID, Vec2{0, 0}, Vec2{1, 1}, 2, 1, atlas, []uint32{EmptyTile, EmptyTile})
+		mustOk(t, err)
+		if hit, _ := g.HitTestShape2D(tileMap.BatchShape, far); hit {
+			t.Error("empty tile map hit a point far outside it")
+		}
+	})
+	t.Run("reset shapes", func(t *testing.T) {
+		g, _ := newTestNull(t)
+		batchID := addTestBatch(t, g, Pos2D|ColFA)
+		addTestRect(t, g, batchID, Rect2D{{0, 0}, {10, 10}})
+		mustOk(t, g.ResetBatchShapes(batchID))
+		hits, err := g.HitTestBatch2D(batchID, far)
+		mustOk(t, err)
+		if len(hits) != 0 {
+			t.Errorf("reset shapes hit a point far outside them: %v", hits)
+		}
+	})
+	t.Run("collapsed indexes", func(t *testing.T) {
+		g, _ := newTestNull(t)
+		batchID := addTestBatch(t, g, Pos2D|ColFA)
+		shape := addTestRect(t, g, batchID, Rect2D{{0, 0}, {10, 10}})
+		mustOk(t, g.UpdateShapeIndexes(shape, []uint32{0, 0, 0, 0, 0, 0}))
+		if hit, _ := g.HitTestShape2D(shape, Vec2{5, 5}); hit {
+			t.Error("shape with collapsed indexes hit a point")
+		}
+	})
+}
+
+func TestHitTestBatch2DOrder(t *testing.T) {
+	g, _ := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	rect := Rect2D{{0, 0}, {10, 10}}
+	bottom := addTestRect(t, g, batchID, rect)
+	raised := addTestRect(t, g, batchID, rect)
+	top := addTestRect(t, g, batchID, rect)
+	hidden := addTestRect(t, g, batchID, rect)
+	mustOk(t, g.SetShapeLayer(raised, 1))
+	mustOk(t, g.HideShape(hidden))
+	hits, err := g.HitTestBatch2D(batchID, Vec2{5, 5})
+	mustOk(t, err)
+	want := []BatchShape{raised, top, bottom}
+	if len(hits) != len(want) {
+		t.Fatalf("HitTestBatch2D returned %d shapes, want %d", len(hits), len(want))
+	}
+	for i := range want {
+		if hits[i] != want[i] {
+			t.Errorf("hit %d = %+v, want %+v", i, hits[i], want[i])
+		}
+	}
+}
+
+func TestAddShapeWithVertices(t *testing.T) {
+	g, n := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	vertices := []Vertex{
+		{Pos: Vec3{0, 0, 0}, Color: testRed},
+		{Pos: Vec3{1, 0, 0}, Color: testGreen},
+		{Pos: Vec3{0, 1, 0}, Color: testBlue},
+	}
+	prototype := ShapePrototype{VertCount: 3, IndexCount: 3, Indexes: []uint32{0, 1, 2}}
+	n.Log = nil
+	shape, err := g.AddShapeWithVertices(batchID, prototype, vertices)
+	mustOk(t, err)
+	if len(n.Log) != 2 || n.Log[0].Method != "AllocateShapeInBatch" || n.Log[1].Method != "UpdateVerticesInShape" {
+		t.Errorf("logged %v, want one allocation and one vertex upload", n.Log)
+	}
+	for i, want := range vertices {
+		got, err := g.GetVertexInShape(shape, uint32(i))
+		mustOk(t, err)
+		if got.Pos != want.Pos || got.Color != want.Color {
+			t.Errorf("vertex %d = %v, want %v", i, got, want)
+		}
+	}
+	count, _ := g.GetBatchShapeCount(batchID)
+	if _, err := g.AddShapeWithVertices(batchID, prototype, vertices[:2]); !err.IsErr {
+		t.Error("vertex count not matching the prototype returned no error")
+	}
+	if after, _ := g.GetBatchShapeCount(batchID); after != count {
+		t.Error("rejected AddShapeWithVertices allocated a shape")
+	}
+}
+
+const benchmarkMeshVertices = 10000
+
+func benchmarkMesh() (ShapePrototype, []Vertex) {
+	vertices := make([]Vertex, benchmarkMeshVertices)
+	for i := range vertices {
+		vertices[i] = Vertex{Pos: Vec3{float32(i % 100), float32(i / 100), 0}, Color: testRed}
+	}
+	return ShapePrototype{VertCount: benchmarkMeshVertices}, vertices
+}
+
+func BenchmarkAddShapeWithVertices(b *testing.B) {
+	g, n := newTestNull(b)
+	prototype, vertices := benchmarkMesh()
+	batchID := addTestBatch(b, g, Pos2D|ColFA|Pixels)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		// keep the call log from growing across iterations
+		n.Log = nil
+		mustOk(b, g.ClearBatch(batchID))
+		_, err := g.AddShapeWithVertices(batchID, prototype, vertices)
+		mustOk(b, err)
+	}
+}
+
+func BenchmarkAllocateAndUpdateEachVertex(b *testing.B) {
+	g, n := newTestNull(b)
+	prototype, vertices := benchmarkMesh()
+	batchID := addTestBatch(b, g, Pos2D|ColFA|Pixels)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1 {
+		// keep the call log from growing across iterations
+		n.Log = nil
+		mustOk(b, g.ClearBatch(batchID))
+		shape, err := g.AllocateShapeInBatch(batchID, prototype)
+		mustOk(b, err)
+		for v, vert := range vertices {
+			mustOk(b, g.UpdateVertexInShape(shape, uint32(v), vert))
+		}
+	}
+}
+
+func TestTileMapNilAtlas(t *testing.T) {
+	g, _ := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|HasTex|ColFA)
+	if _, err := g.AddTileMap2D(batchID, Vec2{0, 0}, Vec2{1, 1}, 2, 2, nil, []uint32{0, 0, 0, 0}); !err.IsErr {
+		t.Error("AddTileMap2D with a nil atlas returned no error")
+	}
+	if count, _ := g.GetBatchShapeCount(batchID); count != 0 {
+		t.Error("AddTileMap2D with a nil atlas allocated a shape")
+	}
+	atlas := &TextureAtlas{Size: IVec2{16, 16}, Regions: []Rect2D{{{0, 0}, {8, 8}}}}
+	tileMap, err := g.AddTileMap2D(batchID, Vec2{0, 0}, Vec2{1, 1}, 2, 2, atlas, []uint32{0, EmptyTile, 0, 0})
+	mustOk(t, err)
+	tileMap.Atlas = nil
+	if err := g.SetTile(tileMap, 1, 0, 0); !err.IsErr {
+		t.Error("SetTile on a tile map without an atlas returned no error")
+	}
+	mustOk(t, g.SetTile(tileMap, 1, 0, EmptyTile))
+	if _, ok := (*TextureAtlas)(nil).RegionUV(0); ok {
+		t.Error("RegionUV of a nil atlas reported a region")
+	}
+}
+
+func TestPolygonCacheMatchesTrig(t *testing.T) {
+	uncached := GraphicsProvider{GraphicsInterface: NewNullGraphics(testAxes)}
+	cached := uncached
+	cached.EnablePolygonCache = true
+	center := Vec2{3, -2}
+	for _, sides := range []uint32{3, 6, 64} {
+		for _, rotation := range []float32{0, 37.5, -90} {
+			want := uncached.pointsOnCircle(rotation, 2.5, center, sides)
+			got := cached.pointsOnCircle(rotation, 2.5, center, sides)
+			wantRing := uncached.pointsOnRing(rotation, 1, 2, center, sides)
+			gotRing := cached.pointsOnRing(rotation, 1, 2, center, sides)
+			if len(got) != len(want) || len(gotRing) != len(wantRing) {
+				t.Fatalf("%d sides: cached point counts %d and %d, want %d and %d", sides, len(got), len(gotRing), len(want), len(wantRing))
+			}
+			for i := range want {
+				if !vec3Near(got[i].AsVec3(), want[i].AsVec3()) {
+					t.Errorf("%d sides, rotation %v: circle point %d = %v, want %v", sides, rotation, i, got[i], want[i])
+				}
+			}
+			for i := range wantRing {
+				if !vec3Near(gotRing[i].AsVec3(), wantRing[i].AsVec3()) {
+					t.Errorf("%d sides, rotation %v: ring point %d = %v, want %v", sides, rotation, i, gotRing[i], wantRing[i])
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkRotatingPolygons(b *testing.B) {
+	for _, enabled := range []bool{false, true} {
+		name := "uncached"
+		if enabled {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			g, n := newTestNull(b)
+			g.EnablePolygonCache = enabled
+			batchID := addTestBatch(b, g, Pos2D|ColFA)
+			center := Vertex{Color: testRed}
+			shapes := make([]BatchShape, 100)
+			for i := range shapes {
+				var err DeepError
+				shapes[i], err = g.AddRegularPolygon2D(batchID, center, 64, 1, 0, 0.5, 0)
+				mustOk(b, err)
+			}
+			b.ResetTimer()
+			for frame := 0; frame < b.N; frame += 1 {
+				n.Log = nil
+				for _, shape := range shapes {
+					mustOk(b, g.UpdateRegularPolygon2D(shape, center, 64, 1, float32(frame), 0.5, float32(frame)))
+				}
+			}
+		})
+	}
+}
+
+func TestCircleScreenSides(t *testing.T) {
+	g, _ := newTestNull(t)
+	surfaceID, _, err := g.AddDrawSurface(IVec2{200, 200}, 0)
+	mustOk(t, err)
+	rendererID, err := g.AddRenderer(Pos2D|ColFA|Cam2D, nil)
+	mustOk(t, err)
+	for _, tt := range []struct {
+		zoom             float32
+		pixelsPerSegment float32
+		want             uint32
+	}{
+		// a radius of 10 pixels has a circumference of about 62.8
+		{1, 4, 16},
+		{1, 100, 3},
+		{0.001, 4, 3},
+		{1e6, 4, MaxCircleScreenSides},
+		{1, 1e-30, MaxCircleScreenSides},
+	} {
+		mustOk(t, g.SetCamera2D(rendererID, Vec2{}, tt.zoom, 0))
+		sides, err := g.CircleScreenSides(Vec3{}, 10, rendererID, surfaceID, tt.pixelsPerSegment)
+		mustOk(t, err)
+		if sides != tt.want {
+			t.Errorf("zoom %v, %v pixels per segment: %d sides, want %d", tt.zoom, tt.pixelsPerSegment, sides, tt.want)
+		}
+	}
+	for _, pixelsPerSegment := range []float32{0, -2, float32(gomath.NaN())} {
+		if _, err := g.CircleScreenSides(Vec3{}, 10, rendererID, surfaceID, pixelsPerSegment); !err.IsErr {
+			t.Errorf("%v pixels per segment returned no error", pixelsPerSegment)
+		}
+	}
+}
+
+func TestComputeLetterbox(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		windowSize    IVec2
+		contentAspect float32
+		want          IRect2D
+	}{
+		{"window wider than content", IVec2{1920, 1080}, 4.0 / 3.0, IRect2D{{240, 0}, {1680, 1080}}},
+		{"window taller than content", IVec2{800, 1000}, 16.0 / 9.0, IRect2D{{0, 275}, {800, 725}}},
+		{"same aspect", IVec2{1280, 720}, 16.0 / 9.0, IRect2D{{0, 0}, {1280, 720}}},
+		{"empty window", IVec2{0, 720}, 1, IRect2D{}},
+		{"empty content", IVec2{1280, 720}, 0, IRect2D{}},
+	} {
+		if got := ComputeLetterbox(tt.windowSize, tt.contentAspect); got != tt.want {
+			t.Errorf("%s: ComputeLetterbox(%v, %v) = %v, want %v", tt.name, tt.windowSize, tt.contentAspect, got, tt.want)
+		}
+	}
+}
+
+func TestPolygonCacheIsBounded(t *testing.T) {
+	g := GraphicsProvider{GraphicsInterface: NewNullGraphics(testAxes), EnablePolygonCache: true}
+	for sides := uint32(3); sides < 3+polygonCacheLimit*2; sides += 1 {
+		points := g.pointsOnCircle(0, 1, ZeroVec2, sides)
+		if uint32(len(points)) != sides {
+			t.Fatalf("%d sides gave %d points", sides, len(points))
+		}
+	}
+	polygonCacheLock.RLock()
+	defer polygonCacheLock.RUnlock()
+	if len(polygonCache) > polygonCacheLimit {
+		t.Errorf("cache holds %d side counts, more than the limit of %d", len(polygonCache), polygonCacheLimit)
+	}
+}
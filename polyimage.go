@@ -0,0 +1,94 @@
+package polyapp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+
+	utils "github.com/gabe-lee/genutils"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/webp"
+)
+
+// Decodes an encoded image into tightly packed 8 bit RGBA pixels (straight alpha, top row first),
+// ready to use as Texture.Data with ImgType ImgUnknown. When hint is ImgUnknown the format is
+// detected from the data's signature.
+func DecodeImage(data []byte, hint ImageType) (pixels []byte, size IVec2, err DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] DecodeImage():")
+	dErr.IsErr = false
+	if hint == ImgUnknown {
+		hint = DetectImageType(data)
+	}
+	var img image.Image
+	var decodeErr error
+	reader := bytes.NewReader(data)
+	switch hint {
+	case ImgPNG:
+		img, decodeErr = png.Decode(reader)
+	case ImgBMP:
+		img, decodeErr = bmp.Decode(reader)
+	case ImgWEBP:
+		img, decodeErr = webp.Decode(reader)
+	default:
+		return nil, IVec2{}, utils.NewDeepError("[PolyApp] DecodeImage(): data is not a supported image type")
+	}
+	if decodeErr != nil {
+		dErr.AddChildError(decodeErr)
+		return nil, IVec2{}, dErr
+	}
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Rect, img, bounds.Min, draw.Src)
+	return nrgba.Pix, IVec2{int32(bounds.Dx()), int32(bounds.Dy())}, dErr
+}
+
+// Returns the type of the encoded image from its signature, or ImgUnknown if it is not recognized
+func DetectImageType(data []byte) ImageType {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return ImgPNG
+	case bytes.HasPrefix(data, []byte("BM")):
+		return ImgBMP
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return ImgWEBP
+	default:
+		return ImgUnknown
+	}
+}
+
+// Encodes tightly packed 8 bit RGBA pixels (straight alpha, top row first), the same layout DecodeImage returns,
+// as format. PNG and BMP are supported, BMP drops the alpha channel unless some pixel is translucent.
+func EncodeImage(pixels []byte, size IVec2, format ImageType) ([]byte, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] EncodeImage():")
+	dErr.IsErr = false
+	if size.X() < 0 || size.Y() < 0 || len(pixels) != int(size.X())*int(size.Y())*4 {
+		return nil, utils.NewDeepError(fmt.Sprintf("[PolyApp] EncodeImage(): %d bytes of pixels does not match a size of %dx%d (%d bytes expected)", len(pixels), size.X(), size.Y(), int(size.X())*int(size.Y())*4))
+	}
+	img := &image.NRGBA{Pix: pixels, Stride: int(size.X()) * 4, Rect: image.Rect(0, 0, int(size.X()), int(size.Y()))}
+	buf := bytes.Buffer{}
+	switch format {
+	case ImgPNG:
+		dErr.AddChildError(png.Encode(&buf, img))
+	case ImgBMP:
+		dErr.AddChildError(bmp.Encode(&buf, img))
+	default:
+		return nil, utils.NewDeepError(fmt.Sprintf("[PolyApp] EncodeImage(): image type %d is not supported for encoding", format))
+	}
+	if dErr.IsErr {
+		return nil, dErr
+	}
+	return buf.Bytes(), dErr
+}
+
+// Multiplies the RGB of tightly packed 8 bit RGBA pixels by their alpha in place, rounding to nearest,
+// turning straight alpha pixels (as DecodeImage returns) into premultiplied ones. See Texture.Premultiply.
+func PremultiplyAlpha(pixels []byte) {
+	for i := 0; i+3 < len(pixels); i += 4 {
+		a := uint32(pixels[i+3])
+		for c := i; c < i+3; c += 1 {
+			pixels[c] = uint8((uint32(pixels[c])*a + 127) / 255)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package polyapp
+
+import (
+	"testing"
+)
+
+func TestPremultiplyAlpha(t *testing.T) {
+	pixels := []byte{
+		255, 128, 0, 128,
+		200, 100, 50, 255,
+		90, 255, 30, 0,
+		255, 255, 255, 1,
+		7, // a partial pixel is left alone
+	}
+	want := []byte{
+		128, 64, 0, 128,
+		200, 100, 50, 255,
+		0, 0, 0, 0,
+		1, 1, 1, 1,
+		7,
+	}
+	PremultiplyAlpha(pixels)
+	for i := range want {
+		if pixels[i] != want[i] {
+			t.Errorf("byte %d = %d, want %d", i, pixels[i], want[i])
+		}
+	}
+}
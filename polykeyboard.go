@@ -2,6 +2,10 @@ package polyapp
 
 type KeyboardInterface interface {
 	GetKeyboardKeyState(key KeyboardKey) InputState
+	// Returns every modifier active right now OR'ed together, the same value SetCallbackOnKeyPress
+	// would pass for a key pressed at this moment. Either the left or right key counts for Shift,
+	// Control, Alt and Super, and ModCapsLock and ModNumLock report whether the lock is on.
+	GetModifierState() KeyboardMod
 	SetCallbackOnRuneInput(op func(r rune))
 	SetCallbackOnKeyPress(op func(key KeyboardKey, state InputAction, mods KeyboardMod))
 }
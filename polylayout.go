@@ -0,0 +1,92 @@
+package polyapp
+
+// Splits rect into side by side pieces along X, one per ratio, each as wide as its share of the sum of
+// ratios. The pieces are ordered from rect's min X and cover rect exactly, ratios of 0 or less give empty
+// pieces. Without ratios, or if none is above 0, rect is returned as one piece.
+func SplitRect2DHorizontal(rect Rect2D, ratios ...float32) []Rect2D {
+	edges := splitEdges(rect[0].X(), rect[1].X(), ratios)
+	pieces := make([]Rect2D, len(edges)-1)
+	for i := range pieces {
+		pieces[i] = Rect2D{{edges[i], rect[0].Y()}, {edges[i+1], rect[1].Y()}}
+	}
+	return pieces
+}
+
+// Same as SplitRect2DHorizontal, but the pieces are stacked along Y from rect's min Y
+func SplitRect2DVertical(rect Rect2D, ratios ...float32) []Rect2D {
+	edges := splitEdges(rect[0].Y(), rect[1].Y(), ratios)
+	pieces := make([]Rect2D, len(edges)-1)
+	for i := range pieces {
+		pieces[i] = Rect2D{{rect[0].X(), edges[i]}, {rect[1].X(), edges[i+1]}}
+	}
+	return pieces
+}
+
+// Returns the edges between pieces of [min, max] split by ratios, including min and max themselves
+func splitEdges(min float32, max float32, ratios []float32) []float32 {
+	total := float32(0)
+	for _, r := range ratios {
+		if r > 0 {
+			total += r
+		}
+	}
+	if total <= 0 {
+		return []float32{min, max}
+	}
+	edges := make([]float32, len(ratios)+1)
+	edges[0] = min
+	sum := float32(0)
+	for i, r := range ratios {
+		if r > 0 {
+			sum += r
+		}
+		edges[i+1] = min + (max-min)*(sum/total)
+	}
+	// avoid rounding leaving a sliver between the last piece and max
+	edges[len(ratios)] = max
+	return edges
+}
+
+// Returns rect shrunk by margin on every side, or grown if margin is negative. A margin larger than
+// half the rect's width or height collapses that axis to the rect's center.
+func InsetRect2D(rect Rect2D, margin float32) Rect2D {
+	minX, maxX := insetAxis(rect[0].X(), rect[1].X(), margin)
+	minY, maxY := insetAxis(rect[0].Y(), rect[1].Y(), margin)
+	return Rect2D{{minX, minY}, {maxX, maxY}}
+}
+
+func insetAxis(min float32, max float32, margin float32) (float32, float32) {
+	if max-min < margin*2 {
+		center := (min + max) / 2
+		return center, center
+	}
+	return min + margin, max - margin
+}
+
+// Divides rect into cols by rows equal cells with gutter space between neighbouring cells (none around
+// the outside), returned row by row starting at rect's min corner. Returns nil if cols or rows is 0.
+// When the gutters would not leave room for the cells along an axis, they are narrowed to share that
+// axis equally and the cells collapse to zero size instead of turning inside out.
+func GridCells(rect Rect2D, cols uint32, rows uint32, gutter float32) []Rect2D {
+	if cols == 0 || rows == 0 {
+		return nil
+	}
+	cellW, gutterX := gridAxis(rect.W(), cols, gutter)
+	cellH, gutterY := gridAxis(rect.H(), rows, gutter)
+	cells := make([]Rect2D, 0, cols*rows)
+	for row := uint32(0); row < rows; row += 1 {
+		for col := uint32(0); col < cols; col += 1 {
+			min := rect[0].Add(Vec2{float32(col) * (cellW + gutterX), float32(row) * (cellH + gutterY)})
+			cells = append(cells, Rect2D{min, min.Add(Vec2{cellW, cellH})})
+		}
+	}
+	return cells
+}
+
+// Returns the cell size and gutter for count cells along an axis of the given length
+func gridAxis(length float32, count uint32, gutter float32) (float32, float32) {
+	if count > 1 && gutter*float32(count-1) > length {
+		return 0, length / float32(count-1)
+	}
+	return (length - gutter*float32(count-1)) / float32(count), gutter
+}
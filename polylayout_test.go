@@ -0,0 +1,99 @@
+package polyapp
+
+import (
+	"testing"
+)
+
+func TestSplitRect2DSumsToRect(t *testing.T) {
+	rect := Rect2D{{10, -5}, {110.3, 37}}
+	for _, ratios := range [][]float32{nil, {1}, {1, 1}, {1, 2, 3}, {0.1, 0, 7.3, 2}, {0, 0}, {-1, 3}} {
+		for _, vertical := range []bool{false, true} {
+			var pieces []Rect2D
+			length := rect.W()
+			if vertical {
+				pieces = SplitRect2DVertical(rect, ratios...)
+				length = rect.H()
+			} else {
+				pieces = SplitRect2DHorizontal(rect, ratios...)
+			}
+			sum := float32(0)
+			for i, piece := range pieces {
+				if vertical {
+					sum += piece.H()
+					if piece[0].X() != rect[0].X() || piece[1].X() != rect[1].X() {
+						t.Errorf("vertical %v: piece %d spans X %v to %v, want the rect's", ratios, i, piece[0].X(), piece[1].X())
+					}
+				} else {
+					sum += piece.W()
+					if piece[0].Y() != rect[0].Y() || piece[1].Y() != rect[1].Y() {
+						t.Errorf("horizontal %v: piece %d spans Y %v to %v, want the rect's", ratios, i, piece[0].Y(), piece[1].Y())
+					}
+				}
+				if i > 0 && ((vertical && piece[0].Y() != pieces[i-1][1].Y()) || (!vertical && piece[0].X() != pieces[i-1][1].X())) {
+					t.Errorf("vertical=%v %v: piece %d does not start where piece %d ends", vertical, ratios, i, i-1)
+				}
+			}
+			if !near(sum, length) {
+				t.Errorf("vertical=%v %v: pieces sum to %v, want %v", vertical, ratios, sum, length)
+			}
+			if last := pieces[len(pieces)-1]; last[1] != rect[1] {
+				t.Errorf("vertical=%v %v: last piece ends at %v, want %v", vertical, ratios, last[1], rect[1])
+			}
+		}
+	}
+}
+
+func TestGridCellsSumsToRect(t *testing.T) {
+	rect := Rect2D{{0, 0}, {100, 60}}
+	for _, tt := range []struct {
+		cols, rows uint32
+		gutter     float32
+	}{
+		{1, 1, 5},
+		{3, 2, 0},
+		{4, 3, 2.5},
+		{7, 5, 1},
+	} {
+		cells := GridCells(rect, tt.cols, tt.rows, tt.gutter)
+		if uint32(len(cells)) != tt.cols*tt.rows {
+			t.Fatalf("%dx%d: %d cells", tt.cols, tt.rows, len(cells))
+		}
+		width := float32(tt.cols-1) * tt.gutter
+		for col := uint32(0); col < tt.cols; col += 1 {
+			width += cells[col].W()
+		}
+		height := float32(tt.rows-1) * tt.gutter
+		for row := uint32(0); row < tt.rows; row += 1 {
+			height += cells[row*tt.cols].H()
+		}
+		if !near(width, rect.W()) || !near(height, rect.H()) {
+			t.Errorf("%dx%d gutter %v: cells and gutters sum to %vx%v, want %vx%v", tt.cols, tt.rows, tt.gutter, width, height, rect.W(), rect.H())
+		}
+		if last := cells[len(cells)-1]; !near(last[1].X(), rect[1].X()) || !near(last[1].Y(), rect[1].Y()) {
+			t.Errorf("%dx%d gutter %v: last cell ends at %v, want %v", tt.cols, tt.rows, tt.gutter, last[1], rect[1])
+		}
+	}
+	if GridCells(rect, 0, 3, 1) != nil || GridCells(rect, 3, 0, 1) != nil {
+		t.Error("GridCells with no columns or rows returned cells")
+	}
+}
+
+func TestGridCellsGutterWiderThanRect(t *testing.T) {
+	rect := Rect2D{{0, 0}, {10, 100}}
+	cells := GridCells(rect, 3, 2, 20)
+	for i, cell := range cells {
+		if cell.W() < 0 || cell.H() < 0 {
+			t.Errorf("cell %d has a negative size %vx%v", i, cell.W(), cell.H())
+		}
+		if cell[0].X() < rect[0].X() || cell[1].X() > rect[1].X() || cell[0].Y() < rect[0].Y() || cell[1].Y() > rect[1].Y() {
+			t.Errorf("cell %d %v is outside %v", i, cell, rect)
+		}
+	}
+	// the columns collapse with the gutters sharing the width, the rows keep the full gutter
+	if cells[0].W() != 0 || cells[1][0].X() != 5 || cells[2][0].X() != 10 {
+		t.Errorf("columns %v %v %v, want zero width cells at X 0, 5 and 10", cells[0], cells[1], cells[2])
+	}
+	if cells[0].H() != 40 || cells[3][0].Y() != 60 {
+		t.Errorf("rows start at %v and %v with height %v, want 0 and 60 with height 40", cells[0][0].Y(), cells[3][0].Y(), cells[0].H())
+	}
+}
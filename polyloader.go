@@ -0,0 +1,122 @@
+package polyapp
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Runs loading tasks on at most a fixed number of goroutines, so that starting many async loads at
+// once queues them instead of spawning a goroutine each. Workers are only running while there are
+// tasks, an idle pool has no goroutines. Safe for use from any goroutine.
+type LoaderPool struct {
+	lock   sync.Mutex
+	done   *sync.Cond
+	queue  []func()
+	limit  int
+	active int
+	closed bool
+}
+
+// Creates a pool running up to concurrency tasks at once, at least 1
+func NewLoaderPool(concurrency int) *LoaderPool {
+	p := &LoaderPool{limit: 1}
+	p.done = sync.NewCond(&p.lock)
+	p.SetConcurrency(concurrency)
+	return p
+}
+
+// Changes how many tasks may run at once, at least 1. Lowering it lets running tasks finish,
+// the extra workers stop as they do.
+func (p *LoaderPool) SetConcurrency(n int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if n < 1 {
+		n = 1
+	}
+	p.limit = n
+	p.startWorkers()
+}
+
+// Queues task to run on a worker, returning false without queueing it if the pool is closed.
+// Tasks start in the order they are submitted.
+func (p *LoaderPool) Submit(task func()) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return false
+	}
+	p.queue = append(p.queue, task)
+	p.startWorkers()
+	return true
+}
+
+// Returns the number of tasks waiting for a worker
+func (p *LoaderPool) Pending() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.queue)
+}
+
+// Returns the number of tasks running
+func (p *LoaderPool) Active() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.active
+}
+
+// Discards the tasks still waiting, refuses new ones and waits for the running ones to return.
+// Running tasks are not interrupted, tasks that may run long should check their own cancellation.
+// Discarded tasks never run, so anything waiting on their results (such as the channel of an
+// AddTextureAsync using the pool) is never signaled.
+func (p *LoaderPool) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closed = true
+	p.queue = nil
+	for p.active > 0 {
+		p.done.Wait()
+	}
+}
+
+// Starts workers until the limit is reached or every queued task has one, p.lock must be held
+func (p *LoaderPool) startWorkers() {
+	for p.active < p.limit && p.active < len(p.queue) {
+		p.active += 1
+		go p.work()
+	}
+}
+
+func (p *LoaderPool) work() {
+	p.lock.Lock()
+	for len(p.queue) > 0 && p.active <= p.limit {
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.lock.Unlock()
+		task()
+		p.lock.Lock()
+	}
+	p.active -= 1
+	p.done.Broadcast()
+	p.lock.Unlock()
+}
+
+// Returns the app's loader pool, creating it with one worker per CPU on first use. Backends and
+// async loading helpers given this pool share its limit. Close it in Teardown so no loading task
+// outlives the app.
+func (a *App) Loader() *LoaderPool {
+	if a.loader == nil {
+		a.loader = NewLoaderPool(runtime.GOMAXPROCS(0))
+	}
+	return a.loader
+}
+
+// Sets how many loading tasks the app's loader pool runs at once, see LoaderPool.SetConcurrency
+func (a *App) SetLoaderConcurrency(n int) {
+	a.Loader().SetConcurrency(n)
+}
+
+// Queues task on the app's loader pool, see LoaderPool.Submit
+func (a *App) Submit(task func()) bool {
+	return a.Loader().Submit(task)
+}
@@ -0,0 +1,237 @@
+package polyapp
+
+import (
+	"bytes"
+	"fmt"
+
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Computes normals from the triangles described by indices and writes them into each Vertex.Norm.
+// Front faces are the ones wound counter-clockwise when viewed, following XRightYUpZAway().
+//
+// When smooth is false every vertex of a triangle gets that triangle's face normal, so vertices shared
+// between triangles end up with the normal of the last triangle using them (flat shading needs unshared vertices).
+// When smooth is true each vertex gets the average of the face normals around it, weighted by face area.
+// Returns a DeepError without changing any normal if indices is not a list of whole triangles of vertices.
+func (g GraphicsProvider) RecalculateNormals(vertices []Vertex, indices []uint32, smooth bool) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] RecalculateNormals():")
+	dErr.IsErr = false
+	if err := validateTriangleIndexes(vertices, indices); err != nil {
+		dErr.AddChildError(err)
+		return dErr
+	}
+	winding := -g.XRightYUpZAway()[2]
+	if smooth {
+		for i := range vertices {
+			vertices[i].Norm = ZeroVec3
+		}
+	}
+	for i := 0; i < len(indices); i += 3 {
+		a, b, c := indices[i], indices[i+1], indices[i+2]
+		edgeAB := vertices[b].Pos.Sub(vertices[a].Pos)
+		edgeAC := vertices[c].Pos.Sub(vertices[a].Pos)
+		// length of the cross product is twice the triangle area, giving the area weighting for free
+		faceNorm := edgeAB.Cross(edgeAC).Scale(winding)
+		if smooth {
+			vertices[a].Norm = vertices[a].Norm.Add(faceNorm)
+			vertices[b].Norm = vertices[b].Norm.Add(faceNorm)
+			vertices[c].Norm = vertices[c].Norm.Add(faceNorm)
+		} else {
+			if faceNorm.Len() > 0 {
+				faceNorm = faceNorm.Norm()
+			}
+			vertices[a].Norm = faceNorm
+			vertices[b].Norm = faceNorm
+			vertices[c].Norm = faceNorm
+		}
+	}
+	if smooth {
+		for i := range vertices {
+			if vertices[i].Norm.Len() > 0 {
+				vertices[i].Norm = vertices[i].Norm.Norm()
+			}
+		}
+	}
+	return dErr
+}
+
+// Checks that indices describes whole triangles and only refers to existing vertices
+func validateTriangleIndexes(vertices []Vertex, indices []uint32) error {
+	if len(indices)%3 != 0 {
+		return fmt.Errorf("index count %d is not a multiple of 3", len(indices))
+	}
+	for i, index := range indices {
+		if index >= uint32(len(vertices)) {
+			return fmt.Errorf("index %d at position %d is outside the %d vertices", index, i, len(vertices))
+		}
+	}
+	return nil
+}
+
+// Computes per-vertex tangents from the positions, UVs and normals of the triangles described by indices,
+// for use with normal mapping. Normals must already be set (see RecalculateNormals).
+//
+// Each tangent uses 4 Extra blocks starting at extraOffsetBlock, holding the float32 bits of the tangent's
+// X, Y and Z followed by its handedness W (1 or -1, the sign to apply to cross(normal, tangent) to get the bitangent).
+// For example an offset of 0 fills Extra[0:4] and needs an Ex128 batch, an offset of 4 fills Extra[4:8] and needs Ex256.
+// Returns a DeepError without writing any tangent if indices is not a list of whole triangles of vertices.
+func ComputeTangents(vertices []Vertex, indices []uint32, extraOffsetBlock uint32) DeepError {
+	if extraOffsetBlock > uint32(len(VertExtra{}))-4 {
+		return utils.NewDeepError("[PolyApp] ComputeTangents(): extra offset block leaves no room for 4 tangent blocks")
+	}
+	dErr := utils.NewDeepError("[PolyApp] ComputeTangents():")
+	dErr.IsErr = false
+	if err := validateTriangleIndexes(vertices, indices); err != nil {
+		dErr.AddChildError(err)
+		return dErr
+	}
+	tangents := make([]Vec3, len(vertices))
+	bitangents := make([]Vec3, len(vertices))
+	for i := 0; i < len(indices); i += 3 {
+		a, b, c := indices[i], indices[i+1], indices[i+2]
+		edgeAB := vertices[b].Pos.Sub(vertices[a].Pos)
+		edgeAC := vertices[c].Pos.Sub(vertices[a].Pos)
+		uvAB := vertices[b].UV.Sub(vertices[a].UV)
+		uvAC := vertices[c].UV.Sub(vertices[a].UV)
+		det := uvAB.X()*uvAC.Y() - uvAC.X()*uvAB.Y()
+		if det == 0 {
+			continue
+		}
+		r := 1 / det
+		tangent := edgeAB.Scale(uvAC.Y()).Sub(edgeAC.Scale(uvAB.Y())).Scale(r)
+		bitangent := edgeAC.Scale(uvAB.X()).Sub(edgeAB.Scale(uvAC.X())).Scale(r)
+		for _, v := range [3]uint32{a, b, c} {
+			tangents[v] = tangents[v].Add(tangent)
+			bitangents[v] = bitangents[v].Add(bitangent)
+		}
+	}
+	for i := range vertices {
+		norm := vertices[i].Norm
+		// Gram-Schmidt orthogonalize against the normal
+		tangent := tangents[i].Sub(norm.Scale(norm.Dot(tangents[i])))
+		if tangent.Len() > 0 {
+			tangent = tangent.Norm()
+		}
+		handedness := float32(1)
+		if norm.Cross(tangent).Dot(bitangents[i]) < 0 {
+			handedness = -1
+		}
+		vertices[i].Extra[extraOffsetBlock] = math.FtoU32(tangent.X())
+		vertices[i].Extra[extraOffsetBlock+1] = math.FtoU32(tangent.Y())
+		vertices[i].Extra[extraOffsetBlock+2] = math.FtoU32(tangent.Z())
+		vertices[i].Extra[extraOffsetBlock+3] = math.FtoU32(handedness)
+	}
+	return dErr
+}
+
+// Reads every live shape in the batch back into a single mesh, for inspecting or baking generated geometry.
+// Shapes are appended in allocation order (hidden shapes included) with their indexes made relative to the
+// returned vertices. Shapes allocated without indexes get sequential ones, so indices always describes
+// every primitive of the batch's draw mode. Attributes the batch does not store are returned as their No* value.
+func (g GraphicsProvider) ExportBatch(batchID BatchID) (vertices []Vertex, indices []uint32, flags VertexFlags, err DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] ExportBatch():")
+	dErr.IsErr = false
+	flags, err = g.GetBatchVertexFlags(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, nil, flags, dErr
+	}
+	shapes, err := g.GetBatchShapes(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, nil, flags, dErr
+	}
+	for _, shape := range shapes {
+		base := uint32(len(vertices))
+		for i := uint32(0); i < shape.VertexCount; i += 1 {
+			vert, err := g.GetVertexInShape(shape, i)
+			if err.IsErr {
+				dErr.AddChildDeepError(err)
+				return vertices, indices, flags, dErr
+			}
+			vertices = append(vertices, vert)
+		}
+		if shape.IndexCount == 0 {
+			for i := uint32(0); i < shape.VertexCount; i += 1 {
+				indices = append(indices, base+i)
+			}
+			continue
+		}
+		shapeIndexes, err := g.GetIndexesInShape(shape)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return vertices, indices, flags, dErr
+		}
+		for _, index := range shapeIndexes {
+			indices = append(indices, base+index)
+		}
+	}
+	return vertices, indices, flags, dErr
+}
+
+// Exports the batch with ExportBatch and serializes it as Wavefront OBJ text. Positions are always written,
+// texture coordinates (vt) and normals (vn) only when the batch stores them, and vertex colors are written
+// after the position as the common "v x y z r g b" extension when the batch has a color channel.
+// Triangles become faces (f), lines become line elements (l) and pixels become point elements (p).
+// Extra data is not exported.
+func (g GraphicsProvider) ExportBatchOBJ(batchID BatchID) ([]byte, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] ExportBatchOBJ():")
+	dErr.IsErr = false
+	vertices, indices, flags, err := g.ExportBatch(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, dErr
+	}
+	hasTex := flags&TexMask == HasTex
+	hasNorms := flags&NormsMask == Norms
+	hasCol := flags&ColMask != NoCol
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "# PolyApp batch %d: %d vertices, %d indices\n", batchID, len(vertices), len(indices))
+	for _, vert := range vertices {
+		if hasCol {
+			fmt.Fprintf(&out, "v %g %g %g %g %g %g\n", vert.Pos[0], vert.Pos[1], vert.Pos[2], vert.Color[0], vert.Color[1], vert.Color[2])
+		} else {
+			fmt.Fprintf(&out, "v %g %g %g\n", vert.Pos[0], vert.Pos[1], vert.Pos[2])
+		}
+	}
+	if hasTex {
+		for _, vert := range vertices {
+			fmt.Fprintf(&out, "vt %g %g\n", vert.UV[0], vert.UV[1])
+		}
+	}
+	if hasNorms {
+		for _, vert := range vertices {
+			fmt.Fprintf(&out, "vn %g %g %g\n", vert.Norm[0], vert.Norm[1], vert.Norm[2])
+		}
+	}
+	// OBJ indexes start at 1, and each element shares one index across its v, vt and vn lists
+	ref := func(index uint32) string {
+		n := index + 1
+		switch {
+		case hasTex && hasNorms:
+			return fmt.Sprintf("%d/%d/%d", n, n, n)
+		case hasTex:
+			return fmt.Sprintf("%d/%d", n, n)
+		case hasNorms:
+			return fmt.Sprintf("%d//%d", n, n)
+		}
+		return fmt.Sprint(n)
+	}
+	switch flags & DrawMask {
+	case Tris:
+		for i := 0; i+2 < len(indices); i += 3 {
+			fmt.Fprintf(&out, "f %s %s %s\n", ref(indices[i]), ref(indices[i+1]), ref(indices[i+2]))
+		}
+	case Lines:
+		for i := 0; i+1 < len(indices); i += 2 {
+			fmt.Fprintf(&out, "l %d %d\n", indices[i]+1, indices[i+1]+1)
+		}
+	case Pixels:
+		for _, index := range indices {
+			fmt.Fprintf(&out, "p %d\n", index+1)
+		}
+	}
+	return out.Bytes(), dErr
+}
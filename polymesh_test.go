@@ -0,0 +1,183 @@
+package polyapp
+
+import (
+	gomath "math"
+	"testing"
+)
+
+func near(a float32, b float32) bool {
+	return gomath.Abs(float64(a-b)) < 1e-4
+}
+
+func vec3Near(a Vec3, b Vec3) bool {
+	return near(a.X(), b.X()) && near(a.Y(), b.Y()) && near(a.Z(), b.Z())
+}
+
+// Returns the outward normals of a 2x2x2 cube's faces and a mesh with 4 unshared vertices per face, wound
+// counter-clockwise when seen from outside under the given axes
+func testCube(axes Vec3) ([]Vec3, []Vertex, []uint32) {
+	normals := []Vec3{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	var vertices []Vertex
+	var indices []uint32
+	for _, n := range normals {
+		u := Vec3{n.Y(), n.Z(), n.X()}
+		v := n.Cross(u)
+		if axes.Z() > 0 {
+			// +Z away is left-handed, where counter-clockwise faces have the opposite cross product
+			u, v = v, u
+		}
+		base := uint32(len(vertices))
+		for _, corner := range [4][2]float32{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+			vertices = append(vertices, Vertex{Pos: n.Add(u.Scale(corner[0])).Add(v.Scale(corner[1]))})
+		}
+		indices = append(indices, base, base+1, base+2, base+2, base+3, base)
+	}
+	return normals, vertices, indices
+}
+
+func TestRecalculateNormalsCube(t *testing.T) {
+	for _, axes := range []Vec3{{1, 1, 1}, {1, 1, -1}} {
+		for _, smooth := range []bool{false, true} {
+			g := GraphicsProvider{GraphicsInterface: NewNullGraphics(axes)}
+			normals, vertices, indices := testCube(axes)
+			mustOk(t, g.RecalculateNormals(vertices, indices, smooth))
+			for i, vert := range vertices {
+				if want := normals[i/4]; !vec3Near(vert.Norm, want) {
+					t.Errorf("axes %v smooth=%v: vertex %d normal = %v, want %v", axes, smooth, i, vert.Norm, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRecalculateNormalsSharedCubeCorners(t *testing.T) {
+	g := GraphicsProvider{GraphicsInterface: NewNullGraphics(testAxes)}
+	_, faceVertices, faceIndices := testCube(testAxes)
+	// merge the face vertices into the 8 corners
+	var vertices []Vertex
+	indices := make([]uint32, len(faceIndices))
+	for i, index := range faceIndices {
+		pos := faceVertices[index].Pos
+		found := -1
+		for j, vert := range vertices {
+			if vec3Near(vert.Pos, pos) {
+				found = j
+			}
+		}
+		if found < 0 {
+			found = len(vertices)
+			vertices = append(vertices, Vertex{Pos: pos})
+		}
+		indices[i] = uint32(found)
+	}
+	if len(vertices) != 8 {
+		t.Fatalf("merged cube has %d vertices, want 8", len(vertices))
+	}
+	mustOk(t, g.RecalculateNormals(vertices, indices, true))
+	for i, vert := range vertices {
+		if want := vert.Pos.Norm(); !vec3Near(vert.Norm, want) {
+			t.Errorf("corner %d normal = %v, want the diagonal %v", i, vert.Norm, want)
+		}
+	}
+}
+
+func TestRecalculateNormalsSubdividedPlane(t *testing.T) {
+	const cells = 4
+	for _, axes := range []Vec3{{1, 1, 1}, {1, 1, -1}} {
+		g := GraphicsProvider{GraphicsInterface: NewNullGraphics(axes)}
+		var vertices []Vertex
+		var indices []uint32
+		for y := 0; y <= cells; y += 1 {
+			for x := 0; x <= cells; x += 1 {
+				vertices = append(vertices, Vertex{Pos: Vec3{float32(x), float32(y), 0}})
+			}
+		}
+		for y := uint32(0); y < cells; y += 1 {
+			for x := uint32(0); x < cells; x += 1 {
+				a := y*(cells+1) + x
+				// counter-clockwise on a surface with +X right and +Y up
+				indices = append(indices, a, a+1, a+cells+2, a+cells+2, a+cells+1, a)
+			}
+		}
+		mustOk(t, g.RecalculateNormals(vertices, indices, true))
+		// the plane faces the viewer, who looks along the away axis
+		want := Vec3{0, 0, -axes.Z()}
+		for i, vert := range vertices {
+			if !vec3Near(vert.Norm, want) {
+				t.Errorf("axes %v: vertex %d normal = %v, want %v", axes, i, vert.Norm, want)
+			}
+		}
+	}
+}
+
+func TestRecalculateNormalsRejectsBadIndexes(t *testing.T) {
+	g := GraphicsProvider{GraphicsInterface: NewNullGraphics(testAxes)}
+	for name, indices := range map[string][]uint32{
+		"partial triangle": {0, 1, 2, 0},
+		"out of range":     {0, 1, 3},
+	} {
+		vertices := []Vertex{{Pos: Vec3{0, 0, 0}}, {Pos: Vec3{1, 0, 0}}, {Pos: Vec3{0, 1, 0}}}
+		if err := g.RecalculateNormals(vertices, indices, false); !err.IsErr {
+			t.Errorf("%s: no error", name)
+		}
+		for i, vert := range vertices {
+			if vert.Norm != ZeroVec3 {
+				t.Errorf("%s: vertex %d normal changed to %v", name, i, vert.Norm)
+			}
+		}
+	}
+}
+
+func testTangent(vert Vertex, offset uint32) (Vec3, float32) {
+	e := vert.Extra[offset:]
+	return Vec3{gomath.Float32frombits(e[0]), gomath.Float32frombits(e[1]), gomath.Float32frombits(e[2])}, gomath.Float32frombits(e[3])
+}
+
+func TestComputeTangentsQuad(t *testing.T) {
+	g := GraphicsProvider{GraphicsInterface: NewNullGraphics(testAxes)}
+	tests := []struct {
+		name       string
+		mirrorU    bool
+		tangent    Vec3
+		handedness float32
+	}{
+		// UV {0, 0} is the top-left, so V runs down the quad while +Y runs up
+		{"uv", false, Vec3{1, 0, 0}, 1},
+		{"mirrored u", true, Vec3{-1, 0, 0}, -1},
+	}
+	for _, tt := range tests {
+		for _, offset := range []uint32{0, 4} {
+			quad := Rect2D{{-1, -1}, {1, 1}}.Quad()
+			uvQuad := Rect2D{{0, 1}, {1, 0}}.Quad()
+			if tt.mirrorU {
+				uvQuad = Rect2D{{1, 1}, {0, 0}}.Quad()
+			}
+			vertices := make([]Vertex, 4)
+			for i := range vertices {
+				vertices[i] = Vertex{Pos: quad[i].AsVec3(), UV: uvQuad[i]}
+			}
+			indices := quadPrototype().Indexes
+			mustOk(t, g.RecalculateNormals(vertices, indices, true))
+			mustOk(t, ComputeTangents(vertices, indices, offset))
+			for i, vert := range vertices {
+				tangent, handedness := testTangent(vert, offset)
+				if !vec3Near(tangent, tt.tangent) || handedness != tt.handedness {
+					t.Errorf("%s offset %d: vertex %d tangent = %v w %v, want %v w %v", tt.name, offset, i, tangent, handedness, tt.tangent, tt.handedness)
+				}
+			}
+		}
+	}
+}
+
+func TestComputeTangentsRejectsBadInput(t *testing.T) {
+	vertices := []Vertex{{Pos: Vec3{0, 0, 0}}, {Pos: Vec3{1, 0, 0}}, {Pos: Vec3{0, 1, 0}}}
+	if err := ComputeTangents(vertices, []uint32{0, 1, 2}, 5); !err.IsErr {
+		t.Error("offset leaving fewer than 4 blocks returned no error")
+	}
+	if err := ComputeTangents(vertices, []uint32{0, 1, 3}, 0); !err.IsErr {
+		t.Error("out of range index returned no error")
+	}
+	if vertices[0].Extra != (VertExtra{}) {
+		t.Errorf("rejected call wrote tangents %v", vertices[0].Extra)
+	}
+}
@@ -1,11 +1,47 @@
 package polyapp
 
+import (
+	gomath "math"
+	"time"
+)
+
 type MouseInterface interface {
 	GetMouseButtonState(button MouseButton) InputState
+	// Returns the cursor position in screen coordinates, in pixels from the top-left corner of the
+	// primary monitor with +Y down, regardless of XRightYUpZAway()
 	GetMousePosition() Vec2
+	// Returns the cursor position in pixels from the top-left corner of the window's content area
+	// with +Y down, regardless of XRightYUpZAway(), and whether the cursor is inside the window.
+	// These are the screen coordinates ScreenToWorld() expects when the window is the draw surface.
+	GetMousePositionInWindow(windowID uint8) (pos Vec2, inside bool)
+	// Moves the cursor to pos in the window's content area, in the same pixels as GetMousePositionInWindow.
+	// The callback set with SetCallbackOnMouseMove is called as it would be for a real move.
+	SetCursorPosition(windowID uint8, pos Vec2)
+	// Reports a press or release of button as if it came from the mouse: the callbacks set with
+	// SetCallbackOnMouseButton and SetCallbackOnMouseClick receive it, and GetMouseButtonState reports the
+	// button down from a press until its release or the next real change of the button.
+	SendMouseButton(button MouseButton, action InputAction)
 	SetCallbackOnMouseWheelScroll(op func(offset Vec2))
 	SetCallbackOnMouseMove(op func(pos Vec2))
 	SetCallbackOnMouseButton(op func(button MouseButton, state InputAction))
+	// Calls op on every button press with the number of presses in the current run of clicks (2 for a double
+	// click, 3 for a triple click) and the cursor position as for SetCallbackOnMouseMove. The callback set with
+	// SetCallbackOnMouseButton still receives every press and release.
+	SetCallbackOnMouseClick(op func(button MouseButton, clickCount uint8, pos Vec2))
+	// Sets how close together presses of the same button must be, in time and in pixels, to continue a run of
+	// clicks. Defaults to DefaultClickInterval and DefaultClickSlop.
+	SetClickConfig(interval time.Duration, slop float32)
+	// Spreads each mouse wheel step over the following frames, scrolling quickly at first and slowing down
+	// at a rate set by friction (per second, higher stops sooner), for inertial scrolling. The total distance
+	// scrolled is unchanged. Scrolling from trackpads and other devices the platform reports as precise or
+	// continuous is already smooth (and on some platforms already has momentum), so it passes through
+	// unchanged rather than being smoothed twice. Disabled by default.
+	//
+	// The callback set with SetCallbackOnMouseWheelScroll keeps receiving the raw offsets.
+	SetScrollSmoothing(enabled bool, friction float32)
+	// Returns the distance to scroll this frame: the smoothed wheel scrolling plus any continuous scrolling
+	// since the previous frame, or all scrolling since the previous frame when smoothing is disabled
+	GetSmoothedScrollDelta() Vec2
 }
 
 var _ MouseInterface = (*MouseProvider)(nil)
@@ -40,3 +76,77 @@ const (
 	MouseWheelUp
 	MouseWheelDown
 )
+
+const (
+	DefaultClickInterval = 500 * time.Millisecond
+	DefaultClickSlop     = 4
+)
+
+// Counts runs of clicks for SetCallbackOnMouseClick, so every backend counts them the same way
+type ClickCounter struct {
+	Interval time.Duration
+	Slop     float32
+	button   MouseButton
+	count    uint8
+	lastTime time.Time
+	lastPos  Vec2
+}
+
+func NewClickCounter() ClickCounter {
+	return ClickCounter{Interval: DefaultClickInterval, Slop: DefaultClickSlop}
+}
+
+// Records a press of button at pos and returns its click count: one more than the previous press when that was
+// the same button, no more than Interval earlier and no more than Slop pixels away, otherwise 1.
+// The count stops at 255.
+func (c *ClickCounter) Press(button MouseButton, pos Vec2, now time.Time) uint8 {
+	if c.count > 0 && button == c.button && now.Sub(c.lastTime) <= c.Interval && pos.Sub(c.lastPos).Len() <= c.Slop {
+		if c.count < 255 {
+			c.count += 1
+		}
+	} else {
+		c.count = 1
+	}
+	c.button, c.lastTime, c.lastPos = button, now, pos
+	return c.count
+}
+
+// Smooths scrolling for SetScrollSmoothing, so every backend scrolls the same way. Backends add each scroll
+// event with Wheel or Continuous and call Step once per frame to get GetSmoothedScrollDelta's value.
+type ScrollSmoother struct {
+	Enabled  bool
+	Friction float32
+	// wheel distance not yet scrolled, and scrolling to pass through on the next Step
+	remaining Vec2
+	immediate Vec2
+}
+
+// Adds a scroll from a notched wheel, spread over the next frames when Enabled
+func (s *ScrollSmoother) Wheel(offset Vec2) {
+	if s.Enabled {
+		s.remaining = s.remaining.Add(offset)
+	} else {
+		s.immediate = s.immediate.Add(offset)
+	}
+}
+
+// Adds a scroll from a precise or continuous device such as a trackpad, which is never smoothed
+func (s *ScrollSmoother) Continuous(offset Vec2) {
+	s.immediate = s.immediate.Add(offset)
+}
+
+// Advances dt seconds and returns the distance to scroll this frame
+func (s *ScrollSmoother) Step(dt float32) Vec2 {
+	delta := s.immediate
+	s.immediate = ZeroVec2
+	if s.remaining != ZeroVec2 {
+		part := s.remaining.Scale(1 - float32(gomath.Exp(float64(-s.Friction*dt))))
+		// finish once less than a hundredth of a unit is left, rather than decaying forever
+		if !s.Enabled || s.remaining.Sub(part).Len() < 0.01 {
+			part = s.remaining
+		}
+		s.remaining = s.remaining.Sub(part)
+		delta = delta.Add(part)
+	}
+	return delta
+}
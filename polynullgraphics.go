@@ -0,0 +1,1118 @@
+package polyapp
+
+import (
+	"fmt"
+	"image"
+
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// A GraphicsInterface that draws nothing, for testing drawing code without a GPU.
+//
+// Batches, shapes and vertices are kept in memory so everything written can be read back,
+// and every call that changes state or draws is appended to Log. Shaders always compile,
+// and any uniform name is accepted.
+type NullGraphics struct {
+	Axes Vec3
+	// Sizes reported for window surfaces, windows missing from the map report {0, 0}
+	WindowSizes map[uint8]IVec2
+	Log         []NullGraphicsCall
+	// Number of vertex uploads a GPU backend would have made: one per Update*InShape call, except that
+	// writes between BeginBatchUpdate and EndBatchUpdate count once at the outermost EndBatchUpdate
+	VertexUploads int
+	// Reported by GetGraphicsLimits and enforced when creating textures and surfaces
+	Limits GraphicsLimits
+	// nil where a batch was deleted, until AddDrawBatch reuses the ID
+	batches     []*nullBatch
+	renderers   []*nullRenderer
+	surfaces    []nullSurface
+	textures    uint32
+	masking     bool
+	maskSurface SurfaceID
+	// textures added with Texture.Premultiply, whose batches start with BlendPremultipliedAlpha
+	premultiplied map[TextureID]bool
+	// Generation given to the next shape allocated in any batch, shared so shapes of a deleted batch
+	// stay invalid when its ID is reused
+	generation uint32
+}
+
+var _ GraphicsInterface = (*NullGraphics)(nil)
+
+// A single recorded call to NullGraphics. Only the fields relevant to Method are set.
+type NullGraphicsCall struct {
+	Method     string
+	BatchID    BatchID
+	SurfaceID  SurfaceID
+	RendererID RendererID
+	Shape      BatchShape
+	Prototype  ShapePrototype
+	VertNumber uint32
+	Vertices   []Vertex
+	// Area a draw was limited to, empty when it covered the whole surface
+	Clip IRect2D
+}
+
+type nullBatch struct {
+	flags     VertexFlags
+	textureID TextureID
+	blend     BlendMode
+	tint      ColorFA
+	vertices  []Vertex
+	indexes   []uint32
+	freeVerts *BufferZoneLL
+	freeIdxs  *BufferZoneLL
+	shapes    []nullShape
+	instances []nullInstance
+	dirty     []IRect2D
+	// BeginBatchUpdate nesting depth, and the vertices written since the outermost one
+	updateDepth int
+	touched     BufferZone
+}
+
+type nullShape struct {
+	shape     BatchShape
+	hidden    bool
+	layer     int16
+	transform Mat4
+}
+
+type nullInstance struct {
+	transform Mat4
+	color     ColorFA
+}
+
+type nullRenderer struct {
+	flags      VertexFlags
+	shaders    []*Shader
+	camera     Camera
+	depthTest  bool
+	depthWrite bool
+	depthFunc  DepthFunc
+	cullMode   CullMode
+	frontCW    bool // false for the default counter-clockwise front faces
+	srgb       bool
+	uniforms   map[string]any
+}
+
+type nullSurface struct {
+	size     IVec2
+	hasDepth bool
+	isWindow bool
+	windowID uint8
+	deleted  bool
+	// texture of surfaces from AddDrawSurface and AddDrawSurfaceWithDepth
+	textureID TextureID
+	stencil   nullStencil
+	// empty for the whole surface
+	viewport IRect2D
+	// 0 for surfaces that are not multisampled
+	samples uint32
+	// vertical blanks PresentSurface waits for, window surfaces only
+	swapInterval uint32
+}
+
+type nullStencil uint8
+
+const (
+	stencilNone nullStencil = iota
+	stencilKeepInside
+	stencilKeepOutside
+)
+
+// Creates a NullGraphics reporting the given axes from XRightYUpZAway()
+func NewNullGraphics(axes Vec3) *NullGraphics {
+	return &NullGraphics{
+		Axes:        axes,
+		WindowSizes: map[uint8]IVec2{},
+		Limits: GraphicsLimits{
+			MaxTextureSize:      16384,
+			MaxTextureUnits:     16,
+			MaxVertexAttributes: 16,
+			MaxSamples:          16,
+		},
+		premultiplied: map[TextureID]bool{},
+	}
+}
+
+func (n *NullGraphics) XRightYUpZAway() Vec3 {
+	return n.Axes
+}
+
+func (n *NullGraphics) CompileShader(shader *Shader) ([]byte, DeepError) {
+	if shader.Compiled {
+		return shader.Data, nullOk()
+	}
+	return []byte(shader.Code), nullOk()
+}
+
+func (n *NullGraphics) AddRenderer(vertexFlags VertexFlags, shaders []*Shader) (RendererID, DeepError) {
+	id := RendererID(0)
+	for int(id) < len(n.renderers) && n.renderers[id] != nil {
+		id += 1
+	}
+	if int(id) == len(n.renderers) {
+		if len(n.renderers) > 255 {
+			return 0, utils.NewDeepError("[PolyApp] NullGraphics.AddRenderer(): no renderer IDs left")
+		}
+		n.renderers = append(n.renderers, nil)
+	}
+	n.renderers[id] = &nullRenderer{flags: vertexFlags, shaders: shaders, uniforms: map[string]any{}}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "AddRenderer", RendererID: id})
+	return id, nullOk()
+}
+
+func (n *NullGraphics) DeleteRenderer(rendererID RendererID) DeepError {
+	_, err := n.renderer("DeleteRenderer", rendererID)
+	if err.IsErr {
+		return err
+	}
+	n.renderers[rendererID] = nil
+	n.Log = append(n.Log, NullGraphicsCall{Method: "DeleteRenderer", RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) AddDrawBatch(vertexFlags VertexFlags, textureID TextureID, initialSize uint32) (BatchID, DeepError) {
+	id := BatchID(0)
+	for int(id) < len(n.batches) && n.batches[id] != nil {
+		id += 1
+	}
+	if int(id) == len(n.batches) {
+		if len(n.batches) > 255 {
+			return 0, utils.NewDeepError("[PolyApp] NullGraphics.AddDrawBatch(): no batch IDs left")
+		}
+		n.batches = append(n.batches, nil)
+	}
+	blend := BlendAlpha
+	if vertexFlags&TexMask == HasTex && n.premultiplied[textureID] {
+		blend = BlendPremultipliedAlpha
+	}
+	n.batches[id] = &nullBatch{
+		flags:     vertexFlags,
+		textureID: textureID,
+		tint:      ColorFA{1, 1, 1, 1},
+		blend:     blend,
+		vertices:  make([]Vertex, 0, initialSize),
+		indexes:   make([]uint32, 0, initialSize),
+		freeVerts: &BufferZoneLL{},
+		freeIdxs:  &BufferZoneLL{},
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "AddDrawBatch", BatchID: id})
+	return id, nullOk()
+}
+
+func (n *NullGraphics) DeleteBatch(batchID BatchID) DeepError {
+	_, err := n.batch("DeleteBatch", batchID)
+	if err.IsErr {
+		return err
+	}
+	n.batches[batchID] = nil
+	n.Log = append(n.Log, NullGraphicsCall{Method: "DeleteBatch", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) GetBatchVertexFlags(batchID BatchID) (VertexFlags, DeepError) {
+	batch, err := n.batch("GetBatchVertexFlags", batchID)
+	if err.IsErr {
+		return 0, err
+	}
+	return batch.flags, err
+}
+
+func (n *NullGraphics) SetBatchBlendMode(batchID BatchID, mode BlendMode) DeepError {
+	batch, err := n.batch("SetBatchBlendMode", batchID)
+	if err.IsErr {
+		return err
+	}
+	batch.blend = mode
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetBatchBlendMode", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) SetBatchTint(batchID BatchID, tint ColorFA) DeepError {
+	batch, err := n.batch("SetBatchTint", batchID)
+	if err.IsErr {
+		return err
+	}
+	batch.tint = tint
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetBatchTint", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) GetGraphicsLimits() GraphicsLimits {
+	return n.Limits
+}
+
+// Returns a DeepError if size is larger than Limits allows
+func (n *NullGraphics) checkTextureSize(method string, size IVec2) DeepError {
+	if uint32(size.X()) > n.Limits.MaxTextureSize || uint32(size.Y()) > n.Limits.MaxTextureSize {
+		return utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): size %dx%d is larger than the maximum texture size %d", method, size.X(), size.Y(), n.Limits.MaxTextureSize))
+	}
+	return nullOk()
+}
+
+func (n *NullGraphics) AddTexture(texture *Texture) (TextureID, DeepError) {
+	if texture != nil {
+		if err := n.checkTextureSize("AddTexture", texture.Size); err.IsErr {
+			return 0, err
+		}
+	}
+	return n.addTexture("AddTexture", texture)
+}
+
+func (n *NullGraphics) addTexture(method string, texture *Texture) (TextureID, DeepError) {
+	if n.textures > 255 {
+		return 0, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): no texture IDs left", method))
+	}
+	n.textures += 1
+	n.premultiplied[TextureID(n.textures-1)] = texture != nil && texture.Premultiply
+	n.Log = append(n.Log, NullGraphicsCall{Method: method})
+	return TextureID(n.textures - 1), nullOk()
+}
+
+// NullGraphics uploads nothing, so the texture is ready as soon as it is added
+func (n *NullGraphics) AddTextureAsync(texture *Texture) (TextureID, <-chan DeepError) {
+	done := make(chan DeepError, 1)
+	if texture != nil {
+		if err := n.checkTextureSize("AddTextureAsync", texture.Size); err.IsErr {
+			done <- err
+			close(done)
+			return 0, done
+		}
+	}
+	id, err := n.addTexture("AddTextureAsync", texture)
+	done <- err
+	close(done)
+	return id, done
+}
+
+func (n *NullGraphics) AddDrawSurface(size IVec2, mipMaps uint32) (SurfaceID, TextureID, DeepError) {
+	return n.addSurface("AddDrawSurface", size, false)
+}
+
+func (n *NullGraphics) AddDrawSurfaceWithDepth(size IVec2, mipMaps uint32) (SurfaceID, TextureID, DeepError) {
+	return n.addSurface("AddDrawSurfaceWithDepth", size, true)
+}
+
+func (n *NullGraphics) addSurface(method string, size IVec2, hasDepth bool) (SurfaceID, TextureID, DeepError) {
+	if len(n.surfaces) > 255 {
+		return 0, 0, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): no surface IDs left", method))
+	}
+	if err := n.checkTextureSize(method, size); err.IsErr {
+		return 0, 0, err
+	}
+	textureID, err := n.AddTexture(nil)
+	if err.IsErr {
+		return 0, 0, err
+	}
+	n.surfaces = append(n.surfaces, nullSurface{size: size, hasDepth: hasDepth, textureID: textureID})
+	id := SurfaceID(len(n.surfaces) - 1)
+	n.Log = append(n.Log, NullGraphicsCall{Method: method, SurfaceID: id})
+	return id, textureID, err
+}
+
+func (n *NullGraphics) DeleteSurface(surfaceID SurfaceID) DeepError {
+	_, err := n.surface("DeleteSurface", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	if n.masking && n.maskSurface == surfaceID {
+		n.masking = false
+	}
+	n.surfaces[surfaceID] = nullSurface{deleted: true}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "DeleteSurface", SurfaceID: surfaceID})
+	return err
+}
+
+func (n *NullGraphics) AddDrawSurfaceMSAA(size IVec2, mipMaps uint32, samples uint32) (SurfaceID, DeepError) {
+	if len(n.surfaces) > 255 {
+		return 0, utils.NewDeepError("[PolyApp] NullGraphics.AddDrawSurfaceMSAA(): no surface IDs left")
+	}
+	if samples == 0 || samples > 16 || samples&(samples-1) != 0 {
+		return 0, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.AddDrawSurfaceMSAA(): %d samples is not supported, use 1, 2, 4, 8 or 16", samples))
+	}
+	if samples > n.Limits.MaxSamples {
+		return 0, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.AddDrawSurfaceMSAA(): %d samples is more than the maximum of %d", samples, n.Limits.MaxSamples))
+	}
+	if err := n.checkTextureSize("AddDrawSurfaceMSAA", size); err.IsErr {
+		return 0, err
+	}
+	n.surfaces = append(n.surfaces, nullSurface{size: size, samples: samples})
+	id := SurfaceID(len(n.surfaces) - 1)
+	n.Log = append(n.Log, NullGraphicsCall{Method: "AddDrawSurfaceMSAA", SurfaceID: id})
+	return id, nullOk()
+}
+
+func (n *NullGraphics) ResolveSurface(src SurfaceID, dst SurfaceID) DeepError {
+	srcSurface, err := n.surface("ResolveSurface", src)
+	if err.IsErr {
+		return err
+	}
+	dstSurface, err := n.surface("ResolveSurface", dst)
+	if err.IsErr {
+		return err
+	}
+	switch {
+	case srcSurface.samples == 0:
+		return utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.ResolveSurface(): surface %d is not multisampled", src))
+	case dstSurface.samples != 0 || dstSurface.isWindow:
+		return utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.ResolveSurface(): surface %d is not a surface from AddDrawSurface", dst))
+	case srcSurface.size != dstSurface.size:
+		return utils.NewDeepError("[PolyApp] NullGraphics.ResolveSurface(): surfaces are not the same size")
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "ResolveSurface", SurfaceID: dst})
+	return err
+}
+
+func (n *NullGraphics) AddWindowSurface(windowID uint8) (SurfaceID, DeepError) {
+	if len(n.surfaces) > 255 {
+		return 0, utils.NewDeepError("[PolyApp] NullGraphics.AddWindowSurface(): no surface IDs left")
+	}
+	n.surfaces = append(n.surfaces, nullSurface{isWindow: true, windowID: windowID, swapInterval: 1})
+	id := SurfaceID(len(n.surfaces) - 1)
+	n.Log = append(n.Log, NullGraphicsCall{Method: "AddWindowSurface", SurfaceID: id})
+	return id, nullOk()
+}
+
+func (n *NullGraphics) GetSurfaceSize(surfaceID SurfaceID) (IVec2, DeepError) {
+	surface, err := n.surface("GetSurfaceSize", surfaceID)
+	return surface.size, err
+}
+
+func (n *NullGraphics) ClearSurface(surfaceID SurfaceID, baseColor ColorFA) DeepError {
+	_, err := n.surface("ClearSurface", surfaceID)
+	if !err.IsErr {
+		n.Log = append(n.Log, NullGraphicsCall{Method: "ClearSurface", SurfaceID: surfaceID})
+	}
+	return err
+}
+
+func (n *NullGraphics) ClearSurfaceArea(surfaceID SurfaceID, baseColor ColorFA, area IRect2D) DeepError {
+	_, err := n.surface("ClearSurfaceArea", surfaceID)
+	if !err.IsErr {
+		n.Log = append(n.Log, NullGraphicsCall{Method: "ClearSurfaceArea", SurfaceID: surfaceID})
+	}
+	return err
+}
+
+func (n *NullGraphics) SetViewport(surfaceID SurfaceID, viewport IRect2D) DeepError {
+	_, err := n.surface("SetViewport", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	if viewport.W() < 0 || viewport.H() < 0 {
+		return utils.NewDeepError("[PolyApp] NullGraphics.SetViewport(): viewport has a negative size")
+	}
+	n.surfaces[surfaceID].viewport = viewport
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetViewport", SurfaceID: surfaceID})
+	return err
+}
+
+func (n *NullGraphics) GetViewport(surfaceID SurfaceID) (IRect2D, DeepError) {
+	surface, err := n.surface("GetViewport", surfaceID)
+	return surface.viewportRect(), err
+}
+
+// Returns the viewport, or the whole surface if it has none
+func (s nullSurface) viewportRect() IRect2D {
+	if s.viewport.W() <= 0 || s.viewport.H() <= 0 {
+		return IRect2D{{0, 0}, s.size}
+	}
+	return s.viewport
+}
+
+func (n *NullGraphics) ClearSurfaceFull(surfaceID SurfaceID, color ColorFA, depth float32, stencil uint8, clearColor bool, clearDepth bool, clearStencil bool) DeepError {
+	_, err := n.surface("ClearSurfaceFull", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	if clearStencil && n.masking && n.maskSurface == surfaceID {
+		return utils.NewDeepError("[PolyApp] NullGraphics.ClearSurfaceFull(): the surface's stencil mask is still being drawn")
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "ClearSurfaceFull", SurfaceID: surfaceID})
+	return err
+}
+
+func (n *NullGraphics) PresentSurface(surfaceID SurfaceID) DeepError {
+	_, err := n.surface("PresentSurface", surfaceID)
+	if !err.IsErr {
+		n.Log = append(n.Log, NullGraphicsCall{Method: "PresentSurface", SurfaceID: surfaceID})
+	}
+	return err
+}
+
+func (n *NullGraphics) SetSwapInterval(surfaceID SurfaceID, interval uint32) DeepError {
+	surface, err := n.surface("SetSwapInterval", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	if !surface.isWindow {
+		return utils.NewDeepError("[PolyApp] NullGraphics.SetSwapInterval(): surface is not a window surface")
+	}
+	n.surfaces[surfaceID].swapInterval = interval
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetSwapInterval", SurfaceID: surfaceID})
+	return err
+}
+
+func (n *NullGraphics) BeginStencilMask(surfaceID SurfaceID) DeepError {
+	_, err := n.surface("BeginStencilMask", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	if n.masking {
+		return utils.NewDeepError("[PolyApp] NullGraphics.BeginStencilMask(): a stencil mask is already being drawn")
+	}
+	n.masking, n.maskSurface = true, surfaceID
+	n.surfaces[surfaceID].stencil = stencilNone
+	n.Log = append(n.Log, NullGraphicsCall{Method: "BeginStencilMask", SurfaceID: surfaceID})
+	return err
+}
+
+func (n *NullGraphics) EndStencilMask(keepInside bool) DeepError {
+	if !n.masking {
+		return utils.NewDeepError("[PolyApp] NullGraphics.EndStencilMask(): no stencil mask is being drawn")
+	}
+	n.masking = false
+	n.surfaces[n.maskSurface].stencil = stencilKeepOutside
+	if keepInside {
+		n.surfaces[n.maskSurface].stencil = stencilKeepInside
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "EndStencilMask", SurfaceID: n.maskSurface})
+	return nullOk()
+}
+
+func (n *NullGraphics) ClearStencilMask(surfaceID SurfaceID) DeepError {
+	_, err := n.surface("ClearStencilMask", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	if n.masking && n.maskSurface == surfaceID {
+		return utils.NewDeepError("[PolyApp] NullGraphics.ClearStencilMask(): the surface's stencil mask is still being drawn")
+	}
+	n.surfaces[surfaceID].stencil = stencilNone
+	n.Log = append(n.Log, NullGraphicsCall{Method: "ClearStencilMask", SurfaceID: surfaceID})
+	return err
+}
+
+// Returns a fully transparent image the size of the requested area
+func (n *NullGraphics) ReadSurfacePixels(surfaceID SurfaceID, area IRect2D) (image.RGBA, DeepError) {
+	surface, err := n.surface("ReadSurfacePixels", surfaceID)
+	if err.IsErr {
+		return image.RGBA{}, err
+	}
+	if area.W() <= 0 || area.H() <= 0 {
+		area = IRect2D{{0, 0}, surface.size}
+	}
+	return *image.NewRGBA(image.Rect(0, 0, int(area.W()), int(area.H()))), err
+}
+
+func (n *NullGraphics) IsBatchValid(batchID BatchID) bool {
+	return int(batchID) < len(n.batches) && n.batches[batchID] != nil
+}
+
+func (n *NullGraphics) IsShapeValid(shape BatchShape) bool {
+	_, _, err := n.shape("IsShapeValid", shape)
+	return !err.IsErr
+}
+
+func (n *NullGraphics) CanBatchFit(batchID BatchID, prototype ShapePrototype) (bool, DeepError) {
+	batch, err := n.batch("CanBatchFit", batchID)
+	if err.IsErr {
+		return false, err
+	}
+	fits := func(free *BufferZoneLL, used int, capacity int, size uint32) bool {
+		return size <= free.Largest() || size <= uint32(capacity-used)
+	}
+	return fits(batch.freeVerts, len(batch.vertices), cap(batch.vertices), prototype.VertCount) &&
+		fits(batch.freeIdxs, len(batch.indexes), cap(batch.indexes), prototype.IndexCount), err
+}
+
+func (n *NullGraphics) AllocateShapeInBatch(batchID BatchID, prototype ShapePrototype) (BatchShape, DeepError) {
+	batch, err := n.batch("AllocateShapeInBatch", batchID)
+	if err.IsErr {
+		return BatchShape{}, err
+	}
+	err = validatePrototype("NullGraphics.AllocateShapeInBatch", batch.flags, prototype)
+	if err.IsErr {
+		return BatchShape{}, err
+	}
+	shape := BatchShape{
+		BatchID:     batchID,
+		VertexZone:  acquireZone(batch.freeVerts, uint32(len(batch.vertices)), prototype.VertCount),
+		IndexZone:   acquireZone(batch.freeIdxs, uint32(len(batch.indexes)), prototype.IndexCount),
+		VertexCount: prototype.VertCount,
+		IndexCount:  prototype.IndexCount,
+		Generation:  n.generation,
+	}
+	n.generation += 1
+	for uint32(len(batch.vertices)) < shape.VertexZone.End {
+		batch.vertices = append(batch.vertices, NullVert)
+	}
+	for uint32(len(batch.indexes)) < shape.IndexZone.End {
+		batch.indexes = append(batch.indexes, 0)
+	}
+	copy(batch.indexes[shape.IndexZone.Start:shape.IndexZone.End], prototype.Indexes)
+	batch.shapes = append(batch.shapes, nullShape{shape: shape, transform: IdentityMat4})
+	n.Log = append(n.Log, NullGraphicsCall{Method: "AllocateShapeInBatch", BatchID: batchID, Shape: shape, Prototype: prototype})
+	return shape, err
+}
+
+// Takes a zone of the given size from the free list, or from the end of the buffer when no free zone fits
+func acquireZone(free *BufferZoneLL, bufferLen uint32, size uint32) BufferZone {
+	zone := free.Aquire(size, nil)
+	if zone.Len() != size || size == 0 {
+		return BufferZone{Start: bufferLen, End: bufferLen + size}
+	}
+	return zone
+}
+
+func (n *NullGraphics) UpdateVertexInShape(shape BatchShape, vertNumber uint32, vertex Vertex) DeepError {
+	batch, _, err := n.shape("UpdateVertexInShape", shape)
+	if err.IsErr {
+		return err
+	}
+	if vertNumber >= shape.VertexCount {
+		return utils.NewDeepError("[PolyApp] NullGraphics.UpdateVertexInShape(): vertex number is outside the shape")
+	}
+	batch.vertices[shape.VertexZone.Start+vertNumber] = vertex
+	n.uploadVertices(batch, shape.VertexZone.Start+vertNumber, 1)
+	n.Log = append(n.Log, NullGraphicsCall{Method: "UpdateVertexInShape", BatchID: shape.BatchID, Shape: shape, VertNumber: vertNumber, Vertices: []Vertex{vertex}})
+	return err
+}
+
+func (n *NullGraphics) UpdateVerticesInShape(shape BatchShape, firstVert uint32, vertices []Vertex) DeepError {
+	batch, _, err := n.shape("UpdateVerticesInShape", shape)
+	if err.IsErr {
+		return err
+	}
+	if firstVert+uint32(len(vertices)) > shape.VertexCount {
+		return utils.NewDeepError("[PolyApp] NullGraphics.UpdateVerticesInShape(): vertices extend outside the shape")
+	}
+	copy(batch.vertices[shape.VertexZone.Start+firstVert:], vertices)
+	n.uploadVertices(batch, shape.VertexZone.Start+firstVert, uint32(len(vertices)))
+	n.Log = append(n.Log, NullGraphicsCall{Method: "UpdateVerticesInShape", BatchID: shape.BatchID, Shape: shape, VertNumber: firstVert, Vertices: vertices})
+	return err
+}
+
+// Counts an upload of count vertices from start, or adds them to the touched range inside a batch update
+func (n *NullGraphics) uploadVertices(batch *nullBatch, start uint32, count uint32) {
+	if batch.updateDepth == 0 {
+		n.VertexUploads += 1
+		return
+	}
+	if batch.touched.Len() == 0 {
+		batch.touched = BufferZone{Start: start, End: start + count}
+		return
+	}
+	batch.touched.Start = math.Min(batch.touched.Start, start)
+	batch.touched.End = math.Max(batch.touched.End, start+count)
+}
+
+func (n *NullGraphics) BeginBatchUpdate(batchID BatchID) DeepError {
+	batch, err := n.batch("BeginBatchUpdate", batchID)
+	if err.IsErr {
+		return err
+	}
+	batch.updateDepth += 1
+	n.Log = append(n.Log, NullGraphicsCall{Method: "BeginBatchUpdate", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) EndBatchUpdate(batchID BatchID) DeepError {
+	batch, err := n.batch("EndBatchUpdate", batchID)
+	if err.IsErr {
+		return err
+	}
+	if batch.updateDepth == 0 {
+		return utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.EndBatchUpdate(): batch %d has no update to end", batchID))
+	}
+	batch.updateDepth -= 1
+	if batch.updateDepth == 0 && batch.touched.Len() > 0 {
+		n.VertexUploads += 1
+		batch.touched = BufferZone{}
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "EndBatchUpdate", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) GetVertexInShape(shape BatchShape, vertNumber uint32) (Vertex, DeepError) {
+	batch, _, err := n.shape("GetVertexInShape", shape)
+	if err.IsErr {
+		return NullVert, err
+	}
+	if vertNumber >= shape.VertexCount {
+		return NullVert, utils.NewDeepError("[PolyApp] NullGraphics.GetVertexInShape(): vertex number is outside the shape")
+	}
+	return storedVertex(batch.flags, batch.vertices[shape.VertexZone.Start+vertNumber]), err
+}
+
+// Returns the vertex as it would read back from a batch with the given flags,
+// with attributes the batch does not store replaced by their No* values
+func storedVertex(flags VertexFlags, vertex Vertex) Vertex {
+	if flags&PosMask == Pos2D {
+		vertex.Pos[2] = 0
+	}
+	if flags&NormsMask != Norms {
+		vertex.Norm = NoNorm
+	}
+	if flags&TexMask != HasTex {
+		vertex.UV = NoUV
+	}
+	if flags&ColMask == NoCol {
+		vertex.Color = NoColor
+	}
+	for i := flags.ExSize() / 4; i < uint32(len(vertex.Extra)); i += 1 {
+		vertex.Extra[i] = 0
+	}
+	return vertex
+}
+
+func (n *NullGraphics) GetIndexesInShape(shape BatchShape) ([]uint32, DeepError) {
+	batch, _, err := n.shape("GetIndexesInShape", shape)
+	if err.IsErr {
+		return nil, err
+	}
+	indexes := make([]uint32, shape.IndexCount)
+	copy(indexes, batch.indexes[shape.IndexZone.Start:shape.IndexZone.End])
+	return indexes, err
+}
+
+func (n *NullGraphics) UpdateShapeIndexes(shape BatchShape, indexes []uint32) DeepError {
+	batch, _, err := n.shape("UpdateShapeIndexes", shape)
+	if err.IsErr {
+		return err
+	}
+	if uint32(len(indexes)) != shape.IndexCount {
+		return utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.UpdateShapeIndexes(): %d indexes given but the shape has %d", len(indexes), shape.IndexCount))
+	}
+	err = validatePrototype("NullGraphics.UpdateShapeIndexes", batch.flags, ShapePrototype{VertCount: shape.VertexCount, IndexCount: shape.IndexCount, Indexes: indexes})
+	if err.IsErr {
+		return err
+	}
+	copy(batch.indexes[shape.IndexZone.Start:shape.IndexZone.End], indexes)
+	n.Log = append(n.Log, NullGraphicsCall{Method: "UpdateShapeIndexes", BatchID: shape.BatchID, Shape: shape})
+	return err
+}
+
+func (n *NullGraphics) HideShape(shape BatchShape) DeepError {
+	return n.setShapeHidden("HideShape", shape, true)
+}
+
+func (n *NullGraphics) ShowShape(shape BatchShape) DeepError {
+	return n.setShapeHidden("ShowShape", shape, false)
+}
+
+func (n *NullGraphics) IsShapeHidden(shape BatchShape) (bool, DeepError) {
+	batch, i, err := n.shape("IsShapeHidden", shape)
+	if err.IsErr {
+		return false, err
+	}
+	return batch.shapes[i].hidden, err
+}
+
+func (n *NullGraphics) setShapeHidden(method string, shape BatchShape, hidden bool) DeepError {
+	batch, i, err := n.shape(method, shape)
+	if err.IsErr {
+		return err
+	}
+	batch.shapes[i].hidden = hidden
+	n.Log = append(n.Log, NullGraphicsCall{Method: method, BatchID: shape.BatchID, Shape: shape})
+	return err
+}
+
+func (n *NullGraphics) DeleteShape(shape BatchShape) DeepError {
+	batch, i, err := n.shape("DeleteShape", shape)
+	if err.IsErr {
+		return err
+	}
+	batch.shapes = append(batch.shapes[:i], batch.shapes[i+1:]...)
+	batch.freeVerts.Insert(shape.VertexZone)
+	batch.freeIdxs.Insert(shape.IndexZone)
+	n.Log = append(n.Log, NullGraphicsCall{Method: "DeleteShape", BatchID: shape.BatchID, Shape: shape})
+	return err
+}
+
+func (n *NullGraphics) CopyShape(src BatchShape, destBatchID BatchID) (BatchShape, DeepError) {
+	srcBatch, _, err := n.shape("CopyShape", src)
+	if err.IsErr {
+		return BatchShape{}, err
+	}
+	destBatch, err := n.batch("CopyShape", destBatchID)
+	if err.IsErr {
+		return BatchShape{}, err
+	}
+	if !srcBatch.flags.SameAttributes(destBatch.flags) {
+		return BatchShape{}, utils.NewDeepError("[PolyApp] NullGraphics.CopyShape(): destination batch does not have the same vertex attributes as the source")
+	}
+	indexes, err := n.GetIndexesInShape(src)
+	if err.IsErr {
+		return BatchShape{}, err
+	}
+	dest, err := n.AllocateShapeInBatch(destBatchID, ShapePrototype{VertCount: src.VertexCount, IndexCount: src.IndexCount, Indexes: indexes})
+	if err.IsErr {
+		return dest, err
+	}
+	copy(destBatch.vertices[dest.VertexZone.Start:dest.VertexZone.End], srcBatch.vertices[src.VertexZone.Start:src.VertexZone.End])
+	return dest, err
+}
+
+func (n *NullGraphics) SetBatchVisible(batchID BatchID, visible bool) DeepError {
+	batch, err := n.batch("SetBatchVisible", batchID)
+	if err.IsErr {
+		return err
+	}
+	for i := range batch.shapes {
+		batch.shapes[i].hidden = !visible
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetBatchVisible", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) GetBatchShapes(batchID BatchID) ([]BatchShape, DeepError) {
+	batch, err := n.batch("GetBatchShapes", batchID)
+	if err.IsErr {
+		return nil, err
+	}
+	shapes := make([]BatchShape, len(batch.shapes))
+	for i, s := range batch.shapes {
+		shapes[i] = s.shape
+	}
+	return shapes, err
+}
+
+func (n *NullGraphics) GetBatchShapeCount(batchID BatchID) (uint32, DeepError) {
+	batch, err := n.batch("GetBatchShapeCount", batchID)
+	if err.IsErr {
+		return 0, err
+	}
+	return uint32(len(batch.shapes)), err
+}
+
+func (n *NullGraphics) SetShapeLayer(shape BatchShape, layer int16) DeepError {
+	batch, i, err := n.shape("SetShapeLayer", shape)
+	if err.IsErr {
+		return err
+	}
+	batch.shapes[i].layer = layer
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetShapeLayer", BatchID: shape.BatchID, Shape: shape})
+	return err
+}
+
+func (n *NullGraphics) GetShapeLayer(shape BatchShape) (int16, DeepError) {
+	batch, i, err := n.shape("GetShapeLayer", shape)
+	if err.IsErr {
+		return 0, err
+	}
+	return batch.shapes[i].layer, err
+}
+
+func (n *NullGraphics) SetShapeTransform(shape BatchShape, transform Mat4) DeepError {
+	batch, i, err := n.shape("SetShapeTransform", shape)
+	if err.IsErr {
+		return err
+	}
+	batch.shapes[i].transform = transform
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetShapeTransform", BatchID: shape.BatchID, Shape: shape})
+	return err
+}
+
+func (n *NullGraphics) SetRendererDepthTest(rendererID RendererID, enabled bool, writeDepth bool, compare DepthFunc) DeepError {
+	renderer, err := n.renderer("SetRendererDepthTest", rendererID)
+	if err.IsErr {
+		return err
+	}
+	renderer.depthTest, renderer.depthWrite, renderer.depthFunc = enabled, writeDepth, compare
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetRendererDepthTest", RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) SetRendererCullMode(rendererID RendererID, mode CullMode) DeepError {
+	renderer, err := n.renderer("SetRendererCullMode", rendererID)
+	if err.IsErr {
+		return err
+	}
+	renderer.cullMode = mode
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetRendererCullMode", RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) SetRendererFrontFace(rendererID RendererID, windingCCW bool) DeepError {
+	renderer, err := n.renderer("SetRendererFrontFace", rendererID)
+	if err.IsErr {
+		return err
+	}
+	renderer.frontCW = !windingCCW
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetRendererFrontFace", RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) SetRendererSRGB(rendererID RendererID, enabled bool) DeepError {
+	renderer, err := n.renderer("SetRendererSRGB", rendererID)
+	if err.IsErr {
+		return err
+	}
+	renderer.srgb = enabled
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetRendererSRGB", RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) SetRendererUniformFloat(rendererID RendererID, name string, value float32) DeepError {
+	return n.setUniform("SetRendererUniformFloat", rendererID, name, value)
+}
+
+func (n *NullGraphics) SetRendererUniformVec2(rendererID RendererID, name string, value Vec2) DeepError {
+	return n.setUniform("SetRendererUniformVec2", rendererID, name, value)
+}
+
+func (n *NullGraphics) SetRendererUniformVec3(rendererID RendererID, name string, value Vec3) DeepError {
+	return n.setUniform("SetRendererUniformVec3", rendererID, name, value)
+}
+
+func (n *NullGraphics) SetRendererUniformVec4(rendererID RendererID, name string, value Vec4) DeepError {
+	return n.setUniform("SetRendererUniformVec4", rendererID, name, value)
+}
+
+func (n *NullGraphics) SetRendererUniformMat4(rendererID RendererID, name string, value Mat4) DeepError {
+	return n.setUniform("SetRendererUniformMat4", rendererID, name, value)
+}
+
+func (n *NullGraphics) setUniform(method string, rendererID RendererID, name string, value any) DeepError {
+	renderer, err := n.renderer(method, rendererID)
+	if err.IsErr {
+		return err
+	}
+	renderer.uniforms[name] = value
+	n.Log = append(n.Log, NullGraphicsCall{Method: method, RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) SetCamera2D(rendererID RendererID, center Vec2, zoom float32, rotationDeg float32) DeepError {
+	return n.setCamera("SetCamera2D", rendererID, NewCamera2D(center, zoom, rotationDeg))
+}
+
+func (n *NullGraphics) SetCamera3D(rendererID RendererID, position Vec3, target Vec3, up Vec3, fovDeg float32, near float32, far float32) DeepError {
+	return n.setCamera("SetCamera3D", rendererID, NewCamera3D(position, target, up, fovDeg, near, far))
+}
+
+func (n *NullGraphics) setCamera(method string, rendererID RendererID, camera Camera) DeepError {
+	renderer, err := n.renderer(method, rendererID)
+	if err.IsErr {
+		return err
+	}
+	camera.Convention = renderer.camera.Convention
+	renderer.camera = camera
+	n.Log = append(n.Log, NullGraphicsCall{Method: method, RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) SetRendererCoordinateConvention(rendererID RendererID, convention CoordinateConvention) DeepError {
+	renderer, err := n.renderer("SetRendererCoordinateConvention", rendererID)
+	if err.IsErr {
+		return err
+	}
+	renderer.camera.Convention = convention
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetRendererCoordinateConvention", RendererID: rendererID})
+	return err
+}
+
+func (n *NullGraphics) GetCamera(rendererID RendererID) (Camera, DeepError) {
+	renderer, err := n.renderer("GetCamera", rendererID)
+	if err.IsErr {
+		return Camera{}, err
+	}
+	return renderer.camera, err
+}
+
+func (n *NullGraphics) AddInstancedShape(batchID BatchID, prototype ShapePrototype, maxInstances uint32) (InstancedShape, DeepError) {
+	batch, err := n.batch("AddInstancedShape", batchID)
+	if err.IsErr {
+		return InstancedShape{}, err
+	}
+	if batch.flags&InstMask != Instanced {
+		return InstancedShape{}, utils.NewDeepError("[PolyApp] NullGraphics.AddInstancedShape(): batch does not have the Instanced flag")
+	}
+	shape, err := n.AllocateShapeInBatch(batchID, prototype)
+	if err.IsErr {
+		return InstancedShape{}, err
+	}
+	start := uint32(len(batch.instances))
+	batch.instances = append(batch.instances, make([]nullInstance, maxInstances)...)
+	return InstancedShape{
+		BatchShape:   shape,
+		InstanceZone: BufferZone{Start: start, End: start + maxInstances},
+		MaxInstances: maxInstances,
+	}, err
+}
+
+func (n *NullGraphics) SetInstanceTransform(shape InstancedShape, index uint32, transform Mat4, color ColorFA) DeepError {
+	batch, _, err := n.shape("SetInstanceTransform", shape.BatchShape)
+	if err.IsErr {
+		return err
+	}
+	if index >= shape.MaxInstances {
+		return utils.NewDeepError("[PolyApp] NullGraphics.SetInstanceTransform(): instance index is outside the instanced shape")
+	}
+	batch.instances[shape.InstanceZone.Start+index] = nullInstance{transform: transform, color: color}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "SetInstanceTransform", BatchID: shape.BatchID, Shape: shape.BatchShape, VertNumber: index})
+	return err
+}
+
+func (n *NullGraphics) DrawInstanced(shape InstancedShape, surfaceID SurfaceID, rendererID RendererID, instanceCount uint32) DeepError {
+	if instanceCount > shape.MaxInstances {
+		return utils.NewDeepError("[PolyApp] NullGraphics.DrawInstanced(): instance count is larger than the instanced shape")
+	}
+	return n.draw("DrawInstanced", shape.BatchID, surfaceID, rendererID, IRect2D{})
+}
+
+func (n *NullGraphics) MarkBatchDirtyRegion(batchID BatchID, region IRect2D) DeepError {
+	batch, err := n.batch("MarkBatchDirtyRegion", batchID)
+	if err.IsErr {
+		return err
+	}
+	batch.dirty = append(batch.dirty, region)
+	n.Log = append(n.Log, NullGraphicsCall{Method: "MarkBatchDirtyRegion", BatchID: batchID, Clip: region})
+	return err
+}
+
+func (n *NullGraphics) DrawBatch(batchID BatchID, surfaceID SurfaceID, rendererID RendererID, forceRedraw bool) DeepError {
+	clip, ok := n.takeDirtyRegion(batchID, forceRedraw)
+	if !ok {
+		return nullOk()
+	}
+	return n.draw("DrawBatch", batchID, surfaceID, rendererID, clip)
+}
+
+func (n *NullGraphics) DrawBatchClipped(batchID BatchID, surfaceID SurfaceID, rendererID RendererID, clip IRect2D, forceRedraw bool) DeepError {
+	dirty, ok := n.takeDirtyRegion(batchID, forceRedraw)
+	if dirty.W() > 0 && dirty.H() > 0 {
+		clip, ok = IntersectIRect2D(clip, dirty)
+	}
+	if !ok {
+		return nullOk()
+	}
+	return n.draw("DrawBatchClipped", batchID, surfaceID, rendererID, clip)
+}
+
+// Clears the batch's dirty regions, returning their bounding box (empty when nothing was marked or
+// forceRedraw is set), or false if the marked regions cover no area at all
+func (n *NullGraphics) takeDirtyRegion(batchID BatchID, forceRedraw bool) (IRect2D, bool) {
+	if !n.IsBatchValid(batchID) {
+		return IRect2D{}, true
+	}
+	batch := n.batches[batchID]
+	dirty := batch.dirty
+	batch.dirty = batch.dirty[:0]
+	if forceRedraw || len(dirty) == 0 {
+		return IRect2D{}, true
+	}
+	bounds, found := IRect2D{}, false
+	for _, region := range dirty {
+		if region.W() <= 0 || region.H() <= 0 {
+			continue
+		}
+		if !found {
+			bounds, found = region, true
+			continue
+		}
+		bounds = IRect2D{
+			IVec2{math.Min(bounds[0].X(), region[0].X()), math.Min(bounds[0].Y(), region[0].Y())},
+			IVec2{math.Max(bounds[1].X(), region[1].X()), math.Max(bounds[1].Y(), region[1].Y())},
+		}
+	}
+	return bounds, found
+}
+
+func (n *NullGraphics) draw(method string, batchID BatchID, surfaceID SurfaceID, rendererID RendererID, clip IRect2D) DeepError {
+	dErr := utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s():", method))
+	dErr.IsErr = false
+	_, err := n.batch(method, batchID)
+	dErr.AddChildDeepError(err)
+	_, err = n.surface(method, surfaceID)
+	dErr.AddChildDeepError(err)
+	_, err = n.renderer(method, rendererID)
+	dErr.AddChildDeepError(err)
+	if !dErr.IsErr {
+		n.Log = append(n.Log, NullGraphicsCall{Method: method, BatchID: batchID, SurfaceID: surfaceID, RendererID: rendererID, Clip: clip})
+	}
+	return dErr
+}
+
+func (n *NullGraphics) ClearBatch(batchID BatchID) DeepError {
+	batch, err := n.batch("ClearBatch", batchID)
+	if err.IsErr {
+		return err
+	}
+	batch.vertices = batch.vertices[:0]
+	batch.indexes = batch.indexes[:0]
+	batch.instances = batch.instances[:0]
+	batch.shapes = batch.shapes[:0]
+	batch.freeVerts = &BufferZoneLL{}
+	batch.freeIdxs = &BufferZoneLL{}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "ClearBatch", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) ResetBatchShapes(batchID BatchID) DeepError {
+	batch, err := n.batch("ResetBatchShapes", batchID)
+	if err.IsErr {
+		return err
+	}
+	for _, s := range batch.shapes {
+		for v := s.shape.VertexZone.Start; v < s.shape.VertexZone.End; v += 1 {
+			batch.vertices[v] = NullVert
+		}
+	}
+	n.Log = append(n.Log, NullGraphicsCall{Method: "ResetBatchShapes", BatchID: batchID})
+	return err
+}
+
+func (n *NullGraphics) batch(method string, batchID BatchID) (*nullBatch, DeepError) {
+	if !n.IsBatchValid(batchID) {
+		return nil, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): batch %d does not exist", method, batchID))
+	}
+	return n.batches[batchID], nullOk()
+}
+
+// Returns the batch holding shape and the shape's position in the batch's shape list
+func (n *NullGraphics) shape(method string, shape BatchShape) (*nullBatch, int, DeepError) {
+	batch, err := n.batch(method, shape.BatchID)
+	if err.IsErr {
+		return nil, 0, err
+	}
+	for i, s := range batch.shapes {
+		if s.shape == shape {
+			return batch, i, err
+		}
+		if s.shape.VertexZone == shape.VertexZone && s.shape.IndexZone == shape.IndexZone && s.shape.Generation != shape.Generation {
+			return nil, 0, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): shape is stale, it was deleted and its zones reused by a newer shape in batch %d", method, shape.BatchID))
+		}
+	}
+	return nil, 0, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): shape is not live in batch %d", method, shape.BatchID))
+}
+
+func (n *NullGraphics) renderer(method string, rendererID RendererID) (*nullRenderer, DeepError) {
+	if int(rendererID) >= len(n.renderers) || n.renderers[rendererID] == nil {
+		return nil, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): renderer %d does not exist", method, rendererID))
+	}
+	return n.renderers[rendererID], nullOk()
+}
+
+func (n *NullGraphics) surface(method string, surfaceID SurfaceID) (nullSurface, DeepError) {
+	if int(surfaceID) >= len(n.surfaces) {
+		return nullSurface{}, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): surface %d does not exist", method, surfaceID))
+	}
+	surface := n.surfaces[surfaceID]
+	if surface.deleted {
+		return nullSurface{}, utils.NewDeepError(fmt.Sprintf("[PolyApp] NullGraphics.%s(): surface %d was deleted", method, surfaceID))
+	}
+	if surface.isWindow {
+		surface.size = n.WindowSizes[surface.windowID]
+	}
+	return surface, nullOk()
+}
+
+func nullOk() DeepError {
+	dErr := utils.NewDeepError("")
+	dErr.IsErr = false
+	return dErr
+}
@@ -0,0 +1,243 @@
+package polyapp
+
+import (
+	"testing"
+
+	geom "github.com/gabe-lee/gengeom"
+	math "github.com/gabe-lee/genmath"
+)
+
+// Returns the logged calls to method
+func loggedCalls(n *NullGraphics, method string) []NullGraphicsCall {
+	calls := make([]NullGraphicsCall, 0)
+	for _, call := range n.Log {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+func TestNullGraphicsLogsRegularPolygon(t *testing.T) {
+	g, n := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	n.Log = nil
+	center := Vertex{Pos: Vec3{2, 3, 0}, Color: testRed}
+	shape, err := g.AddRegularPolygon2D(batchID, center, 6, 4, 30, 0.5, 0)
+	mustOk(t, err)
+
+	allocations := loggedCalls(n, "AllocateShapeInBatch")
+	if len(allocations) != 1 || allocations[0].Prototype.VertCount != 7 || allocations[0].Prototype.IndexCount != 18 {
+		t.Fatalf("logged allocations %+v, want one of 7 vertices and 18 indexes", allocations)
+	}
+	if allocations[0].Shape != shape {
+		t.Errorf("logged shape %+v, want the returned %+v", allocations[0].Shape, shape)
+	}
+	var written []Vertex
+	for _, call := range n.Log {
+		if call.Shape == shape && (call.Method == "UpdateVerticesInShape" || call.Method == "UpdateVertexInShape") {
+			written = append(written, call.Vertices...)
+		}
+	}
+	if len(written) != 7 {
+		t.Fatalf("logged %d vertex writes, want 7", len(written))
+	}
+	if written[0].Pos != center.Pos {
+		t.Errorf("center vertex at %v, want %v", written[0].Pos, center.Pos)
+	}
+	points := geom.PointsOnCircle(30*math.DEG_TO_RAD, 4, center.Pos.AsVec2(), 6)
+	for i, point := range points {
+		if !vec3Near(written[i+1].Pos, point.AsVec3()) {
+			t.Errorf("outer vertex %d at %v, want %v", i, written[i+1].Pos, point)
+		}
+	}
+}
+
+func TestNullGraphicsSurfaceErrors(t *testing.T) {
+	g, n := newTestNull(t)
+	surfaceID, _, err := g.AddDrawSurface(IVec2{8, 4}, 0)
+	mustOk(t, err)
+	size, err := g.GetSurfaceSize(surfaceID)
+	mustOk(t, err)
+	if size != (IVec2{8, 4}) {
+		t.Errorf("surface size %v, want {8 4}", size)
+	}
+	missing := surfaceID + 5
+	if _, err := g.GetSurfaceSize(missing); !err.IsErr {
+		t.Error("GetSurfaceSize of a missing surface returned no error")
+	}
+	if err := g.ClearSurface(missing, testRed); !err.IsErr {
+		t.Error("ClearSurface of a missing surface returned no error")
+	}
+
+	n.WindowSizes[2] = IVec2{64, 32}
+	windowID, err := g.AddWindowSurface(2)
+	mustOk(t, err)
+	n.WindowSizes[2] = IVec2{100, 50}
+	if size, _ := g.GetSurfaceSize(windowID); size != (IVec2{100, 50}) {
+		t.Errorf("window surface size %v, want it to follow the window to {100 50}", size)
+	}
+
+	for _, samples := range []uint32{0, 3, 32} {
+		if _, err := g.AddDrawSurfaceMSAA(IVec2{8, 4}, 0, samples); !err.IsErr {
+			t.Errorf("AddDrawSurfaceMSAA with %d samples returned no error", samples)
+		}
+	}
+	msaaID, err := g.AddDrawSurfaceMSAA(IVec2{8, 4}, 0, 4)
+	mustOk(t, err)
+	otherSize, _, err := g.AddDrawSurface(IVec2{4, 4}, 0)
+	mustOk(t, err)
+	for name, dst := range map[string]SurfaceID{"multisampled": msaaID, "window": windowID, "different size": otherSize} {
+		if err := g.ResolveSurface(msaaID, dst); !err.IsErr {
+			t.Errorf("ResolveSurface into a %s surface returned no error", name)
+		}
+	}
+	if err := g.ResolveSurface(surfaceID, surfaceID); !err.IsErr {
+		t.Error("ResolveSurface from a surface that is not multisampled returned no error")
+	}
+	mustOk(t, g.ResolveSurface(msaaID, surfaceID))
+}
+
+func TestNullGraphicsRendererErrors(t *testing.T) {
+	g, n := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	surfaceID, _, err := g.AddDrawSurface(IVec2{8, 8}, 0)
+	mustOk(t, err)
+	rendererID, err := g.AddRenderer(Pos2D|ColFA, nil)
+	mustOk(t, err)
+	missing := rendererID + 3
+	if err := g.SetCamera2D(missing, Vec2{}, 1, 0); !err.IsErr {
+		t.Error("SetCamera2D on a missing renderer returned no error")
+	}
+	if err := g.SetRendererDepthTest(missing, true, true, DepthLess); !err.IsErr {
+		t.Error("SetRendererDepthTest on a missing renderer returned no error")
+	}
+	if _, err := g.GetCamera(missing); !err.IsErr {
+		t.Error("GetCamera of a missing renderer returned no error")
+	}
+
+	n.Log = nil
+	if err := g.DrawBatch(batchID, surfaceID, missing, true); !err.IsErr {
+		t.Error("DrawBatch with a missing renderer returned no error")
+	}
+	if err := g.DrawBatch(batchID, surfaceID+1, rendererID, true); !err.IsErr {
+		t.Error("DrawBatch to a missing surface returned no error")
+	}
+	if len(n.Log) != 0 {
+		t.Errorf("failed draws were logged: %+v", n.Log)
+	}
+	mustOk(t, g.DrawBatch(batchID, surfaceID, rendererID, true))
+	draws := loggedCalls(n, "DrawBatch")
+	if len(draws) != 1 || draws[0].BatchID != batchID || draws[0].SurfaceID != surfaceID || draws[0].RendererID != rendererID {
+		t.Errorf("logged draws %+v, want the one successful draw", draws)
+	}
+}
+
+func TestNullGraphicsRejectsStaleShape(t *testing.T) {
+	g, _ := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	stale := addTestRect(t, g, batchID, Rect2D{{0, 0}, {1, 1}})
+	mustOk(t, g.DeleteShape(stale))
+	reused := addTestRect(t, g, batchID, Rect2D{{2, 2}, {3, 3}})
+	if reused.VertexZone != stale.VertexZone || reused.IndexZone != stale.IndexZone {
+		t.Fatalf("new shape zones %v %v did not reuse the deleted %v %v", reused.VertexZone, reused.IndexZone, stale.VertexZone, stale.IndexZone)
+	}
+	if err := g.UpdateVertexInShape(stale, 0, Vertex{Pos: Vec3{9, 9, 0}}); !err.IsErr {
+		t.Error("UpdateVertexInShape with a stale handle returned no error")
+	}
+	if _, err := g.GetVertexInShape(stale, 0); !err.IsErr {
+		t.Error("GetVertexInShape with a stale handle returned no error")
+	}
+	if err := g.DeleteShape(stale); !err.IsErr {
+		t.Error("DeleteShape with a stale handle returned no error")
+	}
+	vert, err := g.GetVertexInShape(reused, 0)
+	mustOk(t, err)
+	if vert.Pos == (Vec3{9, 9, 0}) {
+		t.Error("stale handle wrote into the shape that reused its zones")
+	}
+}
+
+func TestNullGraphicsDeleteBatchReusesID(t *testing.T) {
+	g, _ := newTestNull(t)
+	first := addTestBatch(t, g, Pos2D|ColFA)
+	second := addTestBatch(t, g, Pos2D|ColFA)
+	shape := addTestRect(t, g, first, Rect2D{{0, 0}, {1, 1}})
+	mustOk(t, g.DeleteBatch(first))
+	if g.IsBatchValid(first) || g.IsShapeValid(shape) {
+		t.Error("deleted batch or its shape is still valid")
+	}
+	if err := g.DeleteBatch(first); !err.IsErr {
+		t.Error("deleting a batch twice returned no error")
+	}
+	if !g.IsBatchValid(second) {
+		t.Error("deleting one batch invalidated another")
+	}
+	reused := addTestBatch(t, g, Pos2D|ColFA)
+	if reused != first {
+		t.Errorf("new batch got ID %d, want the freed ID %d", reused, first)
+	}
+	// a shape in the same zones of the reused ID is still a different shape
+	addTestRect(t, g, reused, Rect2D{{0, 0}, {1, 1}})
+	if g.IsShapeValid(shape) {
+		t.Error("shape of the deleted batch is valid in the batch that reused its ID")
+	}
+}
+
+func TestBatchUpdateCoalescesUploads(t *testing.T) {
+	g, n := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	shape, err := g.AddRegularPolygon2D(batchID, Vertex{Color: testRed}, 256, 1, 0, 0.5, 0)
+	mustOk(t, err)
+	n.VertexUploads = 0
+	mustOk(t, g.UpdateRegularPolygon2D(shape, Vertex{Color: testRed}, 256, 2, 0, 0.5, 0))
+	if n.VertexUploads != 1 {
+		t.Errorf("UpdateRegularPolygon2D made %d uploads, want 1", n.VertexUploads)
+	}
+	n.VertexUploads = 0
+	mustOk(t, g.BeginBatchUpdate(batchID))
+	mustOk(t, g.BeginBatchUpdate(batchID))
+	mustOk(t, g.UpdateVertexInShape(shape, 0, Vertex{Color: testRed}))
+	mustOk(t, g.EndBatchUpdate(batchID))
+	if n.VertexUploads != 0 {
+		t.Errorf("inner EndBatchUpdate made %d uploads, want 0", n.VertexUploads)
+	}
+	mustOk(t, g.UpdateVertexInShape(shape, 3, Vertex{Color: testRed}))
+	mustOk(t, g.EndBatchUpdate(batchID))
+	if n.VertexUploads != 1 {
+		t.Errorf("outermost EndBatchUpdate made %d uploads, want 1", n.VertexUploads)
+	}
+	if err := g.EndBatchUpdate(batchID); !err.IsErr {
+		t.Error("unmatched EndBatchUpdate returned no error")
+	}
+}
+
+func BenchmarkBatchUpdate(b *testing.B) {
+	for _, bracketed := range []bool{false, true} {
+		name := "per vertex"
+		if bracketed {
+			name = "bracketed"
+		}
+		b.Run(name, func(b *testing.B) {
+			g, n := newTestNull(b)
+			batchID := addTestBatch(b, g, Pos2D|ColFA)
+			shape, err := g.AddRegularPolygon2D(batchID, Vertex{Color: testRed}, 256, 1, 0, 0.5, 0)
+			mustOk(b, err)
+			n.VertexUploads = 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i += 1 {
+				n.Log = nil
+				if bracketed {
+					mustOk(b, g.BeginBatchUpdate(batchID))
+				}
+				for v := uint32(0); v < shape.VertexCount; v += 1 {
+					mustOk(b, g.UpdateVertexInShape(shape, v, Vertex{Pos: Vec3{float32(v), float32(i), 0}, Color: testRed}))
+				}
+				if bracketed {
+					mustOk(b, g.EndBatchUpdate(batchID))
+				}
+			}
+			b.ReportMetric(float64(n.VertexUploads)/float64(b.N), "uploads/op")
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package polyapp
+
+import (
+	"fmt"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Applies a list of full-screen passes to a texture, ping-ponging between two surfaces it owns.
+// Each pass draws the previous pass's result over the whole destination with DrawFullscreenTexture,
+// using the pass's renderer. Pass renderers should take Pos2D|HasTex vertices and have no camera set.
+type PostProcessChain struct {
+	graphics GraphicsProvider
+	size     IVec2
+	surfaces [2]SurfaceID
+	textures [2]TextureID
+	passes   []RendererID
+}
+
+// Creates a chain whose intermediate surfaces are size pixels, normally the size of the output surface
+func NewPostProcessChain(graphics GraphicsProvider, size IVec2) (*PostProcessChain, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] NewPostProcessChain():")
+	dErr.IsErr = false
+	chain := &PostProcessChain{
+		graphics: graphics,
+		size:     size,
+	}
+	for i := range chain.surfaces {
+		var err DeepError
+		chain.surfaces[i], chain.textures[i], err = graphics.AddDrawSurface(size, 0)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return nil, dErr
+		}
+	}
+	return chain, dErr
+}
+
+// Appends a pass drawn with rendererID, passes run in the order they were added
+func (p *PostProcessChain) AddPass(rendererID RendererID) {
+	p.passes = append(p.passes, rendererID)
+}
+
+// Runs every pass, the first sampling input and the last drawing over the whole of output
+// (alpha blended over what output holds, like BlitTexture)
+func (p *PostProcessChain) Process(input TextureID, output SurfaceID) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] PostProcessChain.Process():")
+	dErr.IsErr = false
+	if len(p.passes) == 0 {
+		dErr.AddChildError(fmt.Errorf("chain has no passes"))
+		return dErr
+	}
+	src := input
+	for i, rendererID := range p.passes {
+		dest := output
+		if i < len(p.passes)-1 {
+			dest = p.surfaces[i%2]
+			err := p.graphics.ClearSurface(dest, ColorFA{})
+			if err.IsErr {
+				dErr.AddChildDeepError(err)
+				return dErr
+			}
+		}
+		err := p.graphics.DrawFullscreenTexture(src, dest, rendererID)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return dErr
+		}
+		src = p.textures[i%2]
+	}
+	return dErr
+}
@@ -0,0 +1,58 @@
+package polyapp
+
+// A small seeded pseudo-random generator (SplitMix64) for procedural shapes. The same seed always produces
+// the same sequence, on every platform and Go version, and no global state is read or changed, so generators
+// can be replayed and tested. Not safe for concurrent use and not suitable for cryptography.
+type PolyRand struct {
+	state uint64
+}
+
+func NewPolyRand(seed uint64) *PolyRand {
+	return &PolyRand{state: seed}
+}
+
+func (r *PolyRand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Returns a value in [0, n), or 0 if n is 0
+func (r *PolyRand) Uint32n(n uint32) uint32 {
+	if n == 0 {
+		return 0
+	}
+	return uint32((r.Uint64() >> 32) * uint64(n) >> 32)
+}
+
+// Returns a value in [0, 1)
+func (r *PolyRand) Float32() float32 {
+	// 24 bits fill a float32 mantissa exactly, so every result is below 1
+	return float32(r.Uint64()>>40) / (1 << 24)
+}
+
+// Returns a value in [min, max)
+func (r *PolyRand) Range(min float32, max float32) float32 {
+	return min + (max-min)*r.Float32()
+}
+
+// Returns count points spread uniformly at random inside rect
+func ScatterPointsInRect(rect Rect2D, count uint32, rand *PolyRand) []Vec2 {
+	points := make([]Vec2, count)
+	for i := range points {
+		points[i] = Vec2{rand.Range(rect[0].X(), rect[1].X()), rand.Range(rect[0].Y(), rect[1].Y())}
+	}
+	return points
+}
+
+// Returns a copy of points with each point moved by up to amount along X and Y, for roughening the
+// outline of regular shapes. Large amounts can make the outline cross itself.
+func JitterPolygon2D(points []Vec2, amount float32, rand *PolyRand) []Vec2 {
+	jittered := make([]Vec2, len(points))
+	for i, p := range points {
+		jittered[i] = p.Add(Vec2{rand.Range(-amount, amount), rand.Range(-amount, amount)})
+	}
+	return jittered
+}
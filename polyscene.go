@@ -0,0 +1,201 @@
+package polyapp
+
+import (
+	utils "github.com/gabe-lee/genutils"
+)
+
+// A node in a Scene, positioned by a 2D transform relative to its parent and optionally drawing a shape.
+// The shape's vertices stay in the node's local space, Scene.Render applies the composed transform
+// with SetShapeTransform.
+type Node struct {
+	parent   *Node
+	children []*Node
+	local    Mat3
+	world    Mat3
+	shape    BatchShape
+	hasShape bool
+	dirty    bool
+}
+
+func NewNode(local Mat3) *Node {
+	return &Node{local: local, world: local, dirty: true}
+}
+
+func NewShapeNode(local Mat3, shape BatchShape) *Node {
+	return &Node{local: local, world: local, shape: shape, hasShape: true, dirty: true}
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Returns the node's children in draw order. The slice must not be modified.
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+func (n *Node) Local() Mat3 {
+	return n.local
+}
+
+// Changes the node's transform, the node and all of its descendants are updated by the next Render
+func (n *Node) SetLocal(local Mat3) {
+	n.local = local
+	n.dirty = true
+}
+
+// Returns the node's transform relative to the scene, as of the last Render
+func (n *Node) World() Mat3 {
+	return n.world
+}
+
+func (n *Node) Shape() (BatchShape, bool) {
+	return n.shape, n.hasShape
+}
+
+// A retained tree of Nodes drawn through their batches. Nodes only update the GPU when their
+// transform, or one of their ancestors' transforms, has changed since the last Render.
+type Scene struct {
+	roots []*Node
+	// shapes of nodes added or removed since the last Render, shown or hidden in order by the next one
+	pending []sceneVisibility
+}
+
+type sceneVisibility struct {
+	shape   BatchShape
+	visible bool
+}
+
+func NewScene() *Scene {
+	return &Scene{}
+}
+
+// Returns the nodes without a parent. The slice must not be modified.
+func (s *Scene) Roots() []*Node {
+	return s.roots
+}
+
+// Adds node and its descendants under parent, or as a root when parent is nil. parent must
+// already be in this scene.
+func (s *Scene) AddNode(parent *Node, node *Node) DeepError {
+	if node.parent != nil || s.isRoot(node) {
+		return utils.NewDeepError("[PolyApp] Scene.AddNode(): node is already in a scene")
+	}
+	if parent == nil {
+		s.roots = append(s.roots, node)
+	} else {
+		top := parent
+		for p := parent; p != nil; p = p.parent {
+			if p == node {
+				return utils.NewDeepError("[PolyApp] Scene.AddNode(): node cannot be added under its own descendant")
+			}
+			top = p
+		}
+		if !s.isRoot(top) {
+			return utils.NewDeepError("[PolyApp] Scene.AddNode(): parent is not in the scene")
+		}
+		node.parent = parent
+		parent.children = append(parent.children, node)
+	}
+	node.dirty = true
+	walkNodes(node, func(n *Node) {
+		if n.hasShape {
+			s.pending = append(s.pending, sceneVisibility{shape: n.shape, visible: true})
+		}
+	})
+	dErr := utils.NewDeepError("[PolyApp] Scene.AddNode():")
+	dErr.IsErr = false
+	return dErr
+}
+
+// Removes node and its descendants from the scene, their shapes are hidden by the next Render
+func (s *Scene) RemoveNode(node *Node) DeepError {
+	switch {
+	case node.parent != nil:
+		node.parent.children = removeNode(node.parent.children, node)
+		node.parent = nil
+	case s.isRoot(node):
+		s.roots = removeNode(s.roots, node)
+	default:
+		return utils.NewDeepError("[PolyApp] Scene.RemoveNode(): node is not in the scene")
+	}
+	walkNodes(node, func(n *Node) {
+		if n.hasShape {
+			s.pending = append(s.pending, sceneVisibility{shape: n.shape, visible: false})
+		}
+	})
+	dErr := utils.NewDeepError("[PolyApp] Scene.RemoveNode():")
+	dErr.IsErr = false
+	return dErr
+}
+
+// Updates the transforms of changed nodes, then draws every batch holding a node's shape once,
+// in the order the batches are first reached walking the tree depth first
+func (s *Scene) Render(graphics GraphicsProvider, surfaceID SurfaceID, rendererID RendererID) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] Scene.Render():")
+	dErr.IsErr = false
+	for _, change := range s.pending {
+		if change.visible {
+			dErr.AddChildDeepError(graphics.ShowShape(change.shape))
+		} else {
+			dErr.AddChildDeepError(graphics.HideShape(change.shape))
+		}
+	}
+	s.pending = s.pending[:0]
+	batches := []BatchID{}
+	seen := map[BatchID]bool{}
+	for _, root := range s.roots {
+		s.updateNode(graphics, root, IdentityMat3, false, &dErr, func(batchID BatchID) {
+			if !seen[batchID] {
+				seen[batchID] = true
+				batches = append(batches, batchID)
+			}
+		})
+	}
+	for _, batchID := range batches {
+		dErr.AddChildDeepError(graphics.DrawBatch(batchID, surfaceID, rendererID, false))
+	}
+	return dErr
+}
+
+func (s *Scene) updateNode(graphics GraphicsProvider, node *Node, parentWorld Mat3, parentChanged bool, dErr *DeepError, useBatch func(batchID BatchID)) {
+	changed := node.dirty || parentChanged
+	if changed {
+		node.world = parentWorld.Mul(node.local)
+		node.dirty = false
+		if node.hasShape {
+			dErr.AddChildDeepError(graphics.SetShapeTransform(node.shape, node.world.ToMat4()))
+		}
+	}
+	if node.hasShape {
+		useBatch(node.shape.BatchID)
+	}
+	for _, child := range node.children {
+		s.updateNode(graphics, child, node.world, changed, dErr, useBatch)
+	}
+}
+
+func (s *Scene) isRoot(node *Node) bool {
+	for _, root := range s.roots {
+		if root == node {
+			return true
+		}
+	}
+	return false
+}
+
+func walkNodes(node *Node, op func(n *Node)) {
+	op(node)
+	for _, child := range node.children {
+		walkNodes(child, op)
+	}
+}
+
+func removeNode(nodes []*Node, node *Node) []*Node {
+	for i, n := range nodes {
+		if n == node {
+			return append(nodes[:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
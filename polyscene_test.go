@@ -0,0 +1,26 @@
+package polyapp
+
+import "testing"
+
+func TestSceneAddNodeRequiresParentInScene(t *testing.T) {
+	scene := NewScene()
+	other := NewScene()
+	root := NewNode(IdentityMat3)
+	mustOk(t, other.AddNode(nil, root))
+	child := NewNode(IdentityMat3)
+	if err := scene.AddNode(root, child); !err.IsErr {
+		t.Error("adding under a parent from another scene returned no error")
+	}
+	if child.Parent() != nil || len(root.Children()) != 0 {
+		t.Error("failed AddNode still linked the node to the parent")
+	}
+	if err := scene.AddNode(NewNode(IdentityMat3), child); !err.IsErr {
+		t.Error("adding under a parent in no scene returned no error")
+	}
+	mustOk(t, other.AddNode(root, child))
+	grandchild := NewNode(IdentityMat3)
+	mustOk(t, other.AddNode(child, grandchild))
+	if grandchild.Parent() != child {
+		t.Error("grandchild was not added under its parent")
+	}
+}
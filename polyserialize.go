@@ -0,0 +1,202 @@
+package polyapp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	gomath "math"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Batch data format written by SerializeBatch, all values little endian:
+//
+//	"PLYB"                 magic
+//	uint16                 format version (batchFormatVersion)
+//	uint16                 VertexFlags
+//	uint32                 shape count
+//	per shape, in allocation order:
+//	  uint32, uint32       vertex count, index count
+//	  uint32 * index count indexes, relative to the shape's first vertex
+//	  per vertex, only the attributes the flags store:
+//	    float32 * 2 or 3   position (3 for Pos3D)
+//	    float32 * 3        normal (Norms)
+//	    float32 * 2        UV (HasTex)
+//	    float32 * 4        color as ColorFA (any color format)
+//	    uint32 * blocks    extra blocks (Ex32..Ex256)
+const (
+	batchFormatMagic   = "PLYB"
+	batchFormatVersion = 1
+)
+
+// Encodes every live shape of the batch (see GetBatchShapes) with its vertices and indexes in a compact,
+// lossless binary format for saving, see DeserializeBatch. Hidden state, layers, transforms and the
+// batch's texture are not stored.
+func (g GraphicsProvider) SerializeBatch(batchID BatchID) ([]byte, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] SerializeBatch():")
+	dErr.IsErr = false
+	flags, err := g.GetBatchVertexFlags(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, dErr
+	}
+	shapes, err := g.GetBatchShapes(batchID)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return nil, dErr
+	}
+	var out bytes.Buffer
+	put := func(values ...uint32) {
+		for _, v := range values {
+			binary.Write(&out, binary.LittleEndian, v)
+		}
+	}
+	putFloats := func(values ...float32) {
+		for _, v := range values {
+			put(gomath.Float32bits(v))
+		}
+	}
+	out.WriteString(batchFormatMagic)
+	binary.Write(&out, binary.LittleEndian, uint16(batchFormatVersion))
+	binary.Write(&out, binary.LittleEndian, uint16(flags))
+	put(uint32(len(shapes)))
+	for _, shape := range shapes {
+		indexes, err := g.GetIndexesInShape(shape)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return nil, dErr
+		}
+		put(shape.VertexCount, shape.IndexCount)
+		put(indexes...)
+		for i := uint32(0); i < shape.VertexCount; i += 1 {
+			vert, err := g.GetVertexInShape(shape, i)
+			if err.IsErr {
+				dErr.AddChildDeepError(err)
+				return nil, dErr
+			}
+			putFloats(vert.Pos[0], vert.Pos[1])
+			if flags&PosMask == Pos3D {
+				putFloats(vert.Pos[2])
+			}
+			if flags&NormsMask == Norms {
+				putFloats(vert.Norm[:]...)
+			}
+			if flags&TexMask == HasTex {
+				putFloats(vert.UV[:]...)
+			}
+			if flags&ColMask != NoCol {
+				putFloats(vert.Color[:]...)
+			}
+			put(vert.Extra[:flags.ExSize()/4]...)
+		}
+	}
+	return out.Bytes(), dErr
+}
+
+// Creates a new batch using textureID from data written by SerializeBatch and adds every stored shape to it
+// in the stored order, so GetBatchShapes returns them in the order they were saved. Returns a DeepError
+// without creating a batch if data is not a batch, was written by a newer format version, is truncated or
+// holds a shape with indexes its vertices or the batch's draw mode can't use.
+func (g GraphicsProvider) DeserializeBatch(data []byte, textureID TextureID) (BatchID, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] DeserializeBatch():")
+	dErr.IsErr = false
+	if len(data) < 12 || string(data[:4]) != batchFormatMagic {
+		dErr.AddChildError(fmt.Errorf("data is not a serialized batch"))
+		return 0, dErr
+	}
+	version := binary.LittleEndian.Uint16(data[4:])
+	if version > batchFormatVersion {
+		dErr.AddChildError(fmt.Errorf("format version %d is newer than the supported version %d", version, batchFormatVersion))
+		return 0, dErr
+	}
+	flags := VertexFlags(binary.LittleEndian.Uint16(data[6:]))
+	pos := 8
+	truncated := false
+	get := func() uint32 {
+		if len(data)-pos < 4 {
+			truncated = true
+			return 0
+		}
+		pos += 4
+		return binary.LittleEndian.Uint32(data[pos-4:])
+	}
+	getFloat := func() float32 {
+		return gomath.Float32frombits(get())
+	}
+	// every vertex and index takes at least 4 bytes, which bounds the counts before allocating
+	fitsData := func(count uint32) bool {
+		return uint64(count)*4 <= uint64(len(data)-pos)
+	}
+	type storedShape struct {
+		prototype ShapePrototype
+		vertices  []Vertex
+	}
+	shapeCount := get()
+	if !fitsData(shapeCount) {
+		truncated = true
+	}
+	shapes := make([]storedShape, 0)
+	totalVerts := uint32(0)
+	for s := uint32(0); s < shapeCount && !truncated; s += 1 {
+		vertCount, indexCount := get(), get()
+		if !fitsData(indexCount) || !fitsData(vertCount) {
+			truncated = true
+			break
+		}
+		shape := storedShape{
+			prototype: ShapePrototype{VertCount: vertCount, IndexCount: indexCount, Indexes: make([]uint32, indexCount)},
+			vertices:  make([]Vertex, vertCount),
+		}
+		for i := range shape.prototype.Indexes {
+			shape.prototype.Indexes[i] = get()
+		}
+		// checked before any batch is created, so bad data never leaves a partly filled batch behind
+		if err := validatePrototype("DeserializeBatch", flags, shape.prototype); err.IsErr && !truncated {
+			dErr.AddChildError(fmt.Errorf("shape %d is invalid", s))
+			dErr.AddChildDeepError(err)
+			return 0, dErr
+		}
+		for i := range shape.vertices {
+			vert := NullVert
+			vert.Pos = Vec3{getFloat(), getFloat(), 0}
+			if flags&PosMask == Pos3D {
+				vert.Pos[2] = getFloat()
+			}
+			if flags&NormsMask == Norms {
+				vert.Norm = Vec3{getFloat(), getFloat(), getFloat()}
+			}
+			if flags&TexMask == HasTex {
+				vert.UV = Vec2{getFloat(), getFloat()}
+			}
+			if flags&ColMask != NoCol {
+				vert.Color = ColorFA{getFloat(), getFloat(), getFloat(), getFloat()}
+			}
+			vert.Extra = VertExtra{}
+			for b := uint32(0); b < flags.ExSize()/4; b += 1 {
+				vert.Extra[b] = get()
+			}
+			shape.vertices[i] = vert
+		}
+		shapes = append(shapes, shape)
+		totalVerts += vertCount
+	}
+	if truncated {
+		dErr.AddChildError(fmt.Errorf("data ends before the %d shapes it declares", shapeCount))
+		return 0, dErr
+	}
+	batchID, err := g.AddDrawBatch(flags, textureID, totalVerts)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return batchID, dErr
+	}
+	dErr.AddChildDeepError(g.BeginBatchUpdate(batchID))
+	for _, shape := range shapes {
+		_, err := g.AddShapeWithVertices(batchID, shape.prototype, shape.vertices)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			break
+		}
+	}
+	dErr.AddChildDeepError(g.EndBatchUpdate(batchID))
+	return batchID, dErr
+}
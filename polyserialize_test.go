@@ -0,0 +1,94 @@
+package polyapp
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestSerializeBatchRoundTrip(t *testing.T) {
+	g, _ := newTestNull(t)
+	flags := Pos3D | Norms | HasTex | ColFA | Ex64
+	batchID := addTestBatch(t, g, flags)
+	var shapes []BatchShape
+	for s := 0; s < 3; s += 1 {
+		vertices := make([]Vertex, 4)
+		for i := range vertices {
+			f := float32(s*10 + i)
+			vertices[i] = Vertex{
+				Pos:   Vec3{f, -f, f / 3},
+				Norm:  Vec3{0, 0, -1},
+				UV:    Vec2{f / 7, 1 - f/7},
+				Color: ColorFA{f / 40, 0.1, 0.7, 1 / (f + 1)},
+				Extra: VertExtra{uint32(s), 0xFFFFFFFF - uint32(i)},
+			}
+		}
+		shape, err := g.AddShapeWithVertices(batchID, quadPrototype(), vertices)
+		mustOk(t, err)
+		shapes = append(shapes, shape)
+	}
+	mustOk(t, g.DeleteShape(shapes[1]))
+	shapes = append(shapes[:1], shapes[2:]...)
+
+	data, err := g.SerializeBatch(batchID)
+	mustOk(t, err)
+	loadedID, err := g.DeserializeBatch(data, 0)
+	mustOk(t, err)
+	if loadedFlags, _ := g.GetBatchVertexFlags(loadedID); loadedFlags != flags {
+		t.Errorf("loaded flags %v, want %v", loadedFlags, flags)
+	}
+	loaded, err := g.GetBatchShapes(loadedID)
+	mustOk(t, err)
+	if len(loaded) != len(shapes) {
+		t.Fatalf("loaded %d shapes, want %d", len(loaded), len(shapes))
+	}
+	for s, shape := range shapes {
+		wantIndexes, _ := g.GetIndexesInShape(shape)
+		gotIndexes, _ := g.GetIndexesInShape(loaded[s])
+		if len(gotIndexes) != len(wantIndexes) {
+			t.Fatalf("shape %d: %d indexes, want %d", s, len(gotIndexes), len(wantIndexes))
+		}
+		for i := range wantIndexes {
+			if gotIndexes[i] != wantIndexes[i] {
+				t.Errorf("shape %d: index %d = %d, want %d", s, i, gotIndexes[i], wantIndexes[i])
+			}
+		}
+		for i := uint32(0); i < shape.VertexCount; i += 1 {
+			want, _ := g.GetVertexInShape(shape, i)
+			got, _ := g.GetVertexInShape(loaded[s], i)
+			if got != want {
+				t.Errorf("shape %d: vertex %d = %+v, want %+v", s, i, got, want)
+			}
+		}
+	}
+}
+
+func TestDeserializeBatchRejectsBadData(t *testing.T) {
+	g, n := newTestNull(t)
+	batchID := addTestBatch(t, g, Pos2D|ColFA)
+	addTestRect(t, g, batchID, Rect2D{{0, 0}, {1, 1}})
+	data, err := g.SerializeBatch(batchID)
+	mustOk(t, err)
+
+	badIndex := append([]byte{}, data...)
+	// the first index follows the header, shape count and the shape's vertex and index counts
+	binary.LittleEndian.PutUint32(badIndex[20:], 4)
+	oddCount := append([]byte{}, data...)
+	binary.LittleEndian.PutUint32(oddCount[16:], 5)
+	newer := append([]byte{}, data...)
+	binary.LittleEndian.PutUint16(newer[4:], batchFormatVersion+1)
+	for name, bad := range map[string][]byte{
+		"index past the shape's vertices": badIndex,
+		"index count not a multiple of 3": oddCount,
+		"truncated":                       data[:len(data)-1],
+		"newer version":                   newer,
+		"not a batch":                     []byte("not a batch at all"),
+	} {
+		batches := len(n.batches)
+		if _, err := g.DeserializeBatch(bad, 0); !err.IsErr {
+			t.Errorf("%s: no error", name)
+		}
+		if len(n.batches) != batches {
+			t.Errorf("%s: a batch was created", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package polyapp
+
+import (
+	"fmt"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+// A fixed set of identical shapes allocated once and handed out again and again, for particles and other
+// shapes that come and go every frame. Acquiring and releasing never allocates or frees batch space, so the
+// batch's free lists never fragment. Free shapes hold NullVert vertices, so they draw nothing.
+type ShapePool struct {
+	graphics GraphicsProvider
+	shapes   []BatchShape
+	free     []uint32
+	inUse    []bool
+	// NullVert repeated once per prototype vertex, written over released shapes
+	blank []Vertex
+}
+
+// Allocates capacity shapes from prototype in the batch, all starting free
+func (g GraphicsProvider) NewShapePool(batchID BatchID, prototype ShapePrototype, capacity uint32) (*ShapePool, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] NewShapePool():")
+	dErr.IsErr = false
+	pool := &ShapePool{
+		graphics: g,
+		shapes:   make([]BatchShape, capacity),
+		free:     make([]uint32, capacity),
+		inUse:    make([]bool, capacity),
+		blank:    make([]Vertex, prototype.VertCount),
+	}
+	for i := range pool.blank {
+		pool.blank[i] = NullVert
+	}
+	dErr.AddChildDeepError(g.BeginBatchUpdate(batchID))
+	for i := uint32(0); i < capacity; i += 1 {
+		shape, err := g.AllocateShapeInBatch(batchID, prototype)
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			for _, allocated := range pool.shapes[:i] {
+				dErr.AddChildDeepError(g.DeleteShape(allocated))
+			}
+			dErr.AddChildDeepError(g.EndBatchUpdate(batchID))
+			return nil, dErr
+		}
+		pool.shapes[i] = shape
+		// handed out from the end, so reverse the order to hand out the first shape first
+		pool.free[capacity-1-i] = i
+		dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, pool.blank))
+	}
+	dErr.AddChildDeepError(g.EndBatchUpdate(batchID))
+	return pool, dErr
+}
+
+// Returns a free shape, or false if every shape is in use. Its vertices are NullVert until updated.
+func (p *ShapePool) Acquire() (BatchShape, bool) {
+	if len(p.free) == 0 {
+		return BatchShape{}, false
+	}
+	index := p.free[len(p.free)-1]
+	p.free = p.free[:len(p.free)-1]
+	p.inUse[index] = true
+	return p.shapes[index], true
+}
+
+// Blanks the shape's vertices to NullVert and makes it available to Acquire again
+func (p *ShapePool) Release(shape BatchShape) DeepError {
+	for i := range p.shapes {
+		if p.shapes[i] != shape {
+			continue
+		}
+		if !p.inUse[i] {
+			return utils.NewDeepError(fmt.Sprintf("[PolyApp] ShapePool.Release(): shape %d is already free", i))
+		}
+		p.inUse[i] = false
+		p.free = append(p.free, uint32(i))
+		return p.graphics.UpdateVerticesInShape(shape, 0, p.blank)
+	}
+	return utils.NewDeepError("[PolyApp] ShapePool.Release(): shape does not belong to the pool")
+}
+
+// Returns the number of shapes that can be acquired
+func (p *ShapePool) Available() uint32 {
+	return uint32(len(p.free))
+}
+
+// Deletes every shape of the pool, whether acquired or not, making the pool unusable
+func (p *ShapePool) Delete() DeepError {
+	dErr := utils.NewDeepError("[PolyApp] ShapePool.Delete():")
+	dErr.IsErr = false
+	for _, shape := range p.shapes {
+		dErr.AddChildDeepError(p.graphics.DeleteShape(shape))
+	}
+	p.shapes, p.free, p.inUse = nil, nil, nil
+	return dErr
+}
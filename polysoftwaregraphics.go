@@ -0,0 +1,773 @@
+package polyapp
+
+import (
+	"image"
+	"image/draw"
+	"sort"
+	"sync"
+
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+)
+
+// A GraphicsInterface that rasterizes batches on the CPU into in-memory images.
+// It is meant to be simple and correct rather than fast, for tests, thumbnails and
+// platforms without a GPU.
+//
+// Surfaces are image.RGBA buffers which can also be sampled as textures. Triangles, 1 pixel lines and
+// pixels are rasterized with per-vertex color and UV interpolation (perspective correct), nearest texture
+// sampling (UV {0, 0} is the top-left texel), batch blend modes and tint, depth testing on surfaces
+// created with AddDrawSurfaceWithDepth, and clipping. Shaders and uniforms are accepted but ignored.
+//
+// Shape storage and bookkeeping is inherited from NullGraphics, so Log records calls the same way.
+type SoftwareGraphics struct {
+	*NullGraphics
+	surfaceImages []*image.RGBA
+	depthBuffers  [][]float32
+	stencils      [][]bool
+	textureImages map[TextureID]*image.RGBA
+	// textures decoded by AddTextureAsync workers, waiting for the next draw call to swap them in
+	pendingLock     sync.Mutex
+	pendingTextures []softwarePendingTexture
+	// When set, AddTextureAsync decodes on this pool (such as App.Loader()) instead of its own goroutine
+	Loader *LoaderPool
+	// the last frame PresentSurface showed on each window surface
+	presented map[SurfaceID]*image.RGBA
+}
+
+type softwarePendingTexture struct {
+	id   TextureID
+	img  *image.RGBA
+	done chan DeepError
+}
+
+var _ GraphicsInterface = (*SoftwareGraphics)(nil)
+
+// Creates a SoftwareGraphics reporting the given axes from XRightYUpZAway()
+func NewSoftwareGraphics(axes Vec3) *SoftwareGraphics {
+	return &SoftwareGraphics{
+		NullGraphics:  NewNullGraphics(axes),
+		textureImages: map[TextureID]*image.RGBA{},
+		presented:     map[SurfaceID]*image.RGBA{},
+	}
+}
+
+// Adds a texture from texture.Data, which is either raw RGBA8 pixels (straight alpha) of texture.Size
+// when ImgType is ImgUnknown, or an encoded image that DecodeImage accepts
+func (s *SoftwareGraphics) AddTexture(texture *Texture) (TextureID, DeepError) {
+	if texture == nil {
+		return 0, utils.NewDeepError("[PolyApp] SoftwareGraphics.AddTexture(): texture is nil")
+	}
+	dErr := utils.NewDeepError("[PolyApp] SoftwareGraphics.AddTexture():")
+	dErr.IsErr = false
+	img, err := decodeTextureImage(texture)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return 0, dErr
+	}
+	// encoded images only have a size once decoded
+	err = s.checkTextureSize("AddTexture", IVec2{int32(img.Rect.Dx()), int32(img.Rect.Dy())})
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return 0, dErr
+	}
+	id, err := s.NullGraphics.AddTexture(texture)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return id, dErr
+	}
+	s.textureImages[id] = img
+	return id, dErr
+}
+
+func (s *SoftwareGraphics) AddTextureAsync(texture *Texture) (TextureID, <-chan DeepError) {
+	done := make(chan DeepError, 1)
+	if texture == nil {
+		done <- utils.NewDeepError("[PolyApp] SoftwareGraphics.AddTextureAsync(): texture is nil")
+		close(done)
+		return 0, done
+	}
+	id, reserved := s.NullGraphics.AddTextureAsync(texture)
+	if err := <-reserved; err.IsErr {
+		done <- err
+		close(done)
+		return id, done
+	}
+	placeholder := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	copy(placeholder.Pix, []uint8{255, 255, 255, 255})
+	s.textureImages[id] = placeholder
+	decode := func() {
+		img, err := decodeTextureImage(texture)
+		if !err.IsErr {
+			// encoded images only have a size once decoded, the placeholder stays if it is too large
+			err = s.checkTextureSize("AddTextureAsync", IVec2{int32(img.Rect.Dx()), int32(img.Rect.Dy())})
+		}
+		if err.IsErr {
+			dErr := utils.NewDeepError("[PolyApp] SoftwareGraphics.AddTextureAsync():")
+			dErr.AddChildDeepError(err)
+			done <- dErr
+			close(done)
+			return
+		}
+		s.pendingLock.Lock()
+		s.pendingTextures = append(s.pendingTextures, softwarePendingTexture{id: id, img: img, done: done})
+		s.pendingLock.Unlock()
+	}
+	if s.Loader == nil {
+		go decode()
+	} else if !s.Loader.Submit(decode) {
+		done <- utils.NewDeepError("[PolyApp] SoftwareGraphics.AddTextureAsync(): loader pool is closed")
+		close(done)
+	}
+	return id, done
+}
+
+// Swaps in every texture AddTextureAsync has finished decoding and signals its channel
+func (s *SoftwareGraphics) uploadPendingTextures() {
+	s.pendingLock.Lock()
+	pending := s.pendingTextures
+	s.pendingTextures = nil
+	s.pendingLock.Unlock()
+	for _, p := range pending {
+		s.textureImages[p.id] = p.img
+		p.done <- nullOk()
+		close(p.done)
+	}
+}
+
+// Decodes texture.Data into a premultiplied image, see AddTexture for the accepted data
+func decodeTextureImage(texture *Texture) (*image.RGBA, DeepError) {
+	pixels, size := texture.Data, texture.Size
+	if texture.ImgType != ImgUnknown || int32(len(pixels)) != size.X()*size.Y()*4 {
+		var err DeepError
+		pixels, size, err = DecodeImage(texture.Data, texture.ImgType)
+		if err.IsErr {
+			return nil, err
+		}
+	}
+	nrgba := &image.NRGBA{Pix: pixels, Stride: int(size.X()) * 4, Rect: image.Rect(0, 0, int(size.X()), int(size.Y()))}
+	img := image.NewRGBA(nrgba.Rect)
+	draw.Draw(img, img.Rect, nrgba, image.Point{}, draw.Src)
+	return img, nullOk()
+}
+
+func (s *SoftwareGraphics) AddDrawSurface(size IVec2, mipMaps uint32) (SurfaceID, TextureID, DeepError) {
+	return s.addSurface(size, mipMaps, false)
+}
+
+func (s *SoftwareGraphics) AddDrawSurfaceWithDepth(size IVec2, mipMaps uint32) (SurfaceID, TextureID, DeepError) {
+	return s.addSurface(size, mipMaps, true)
+}
+
+func (s *SoftwareGraphics) addSurface(size IVec2, mipMaps uint32, hasDepth bool) (SurfaceID, TextureID, DeepError) {
+	var surfaceID SurfaceID
+	var textureID TextureID
+	var err DeepError
+	if hasDepth {
+		surfaceID, textureID, err = s.NullGraphics.AddDrawSurfaceWithDepth(size, mipMaps)
+	} else {
+		surfaceID, textureID, err = s.NullGraphics.AddDrawSurface(size, mipMaps)
+	}
+	if err.IsErr {
+		return surfaceID, textureID, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(size.X()), int(size.Y())))
+	var depth []float32
+	if hasDepth {
+		depth = make([]float32, size.X()*size.Y())
+		for i := range depth {
+			depth[i] = 1
+		}
+	}
+	s.surfaceImages = append(s.surfaceImages, img)
+	s.depthBuffers = append(s.depthBuffers, depth)
+	s.stencils = append(s.stencils, nil)
+	s.textureImages[textureID] = img
+	return surfaceID, textureID, err
+}
+
+func (s *SoftwareGraphics) DeleteSurface(surfaceID SurfaceID) DeepError {
+	surface, err := s.surface("DeleteSurface", surfaceID)
+	if err.IsErr {
+		return err
+	}
+	err = s.NullGraphics.DeleteSurface(surfaceID)
+	if err.IsErr {
+		return err
+	}
+	s.surfaceImages[surfaceID] = nil
+	s.depthBuffers[surfaceID] = nil
+	s.stencils[surfaceID] = nil
+	delete(s.presented, surfaceID)
+	if !surface.isWindow && surface.samples == 0 {
+		delete(s.textureImages, surface.textureID)
+	}
+	return err
+}
+
+// Multisampled surfaces are rasterized with a single sample per pixel, so they look the same as regular surfaces
+func (s *SoftwareGraphics) AddDrawSurfaceMSAA(size IVec2, mipMaps uint32, samples uint32) (SurfaceID, DeepError) {
+	surfaceID, err := s.NullGraphics.AddDrawSurfaceMSAA(size, mipMaps, samples)
+	if err.IsErr {
+		return surfaceID, err
+	}
+	s.surfaceImages = append(s.surfaceImages, image.NewRGBA(image.Rect(0, 0, int(size.X()), int(size.Y()))))
+	s.depthBuffers = append(s.depthBuffers, nil)
+	s.stencils = append(s.stencils, nil)
+	return surfaceID, err
+}
+
+func (s *SoftwareGraphics) ResolveSurface(src SurfaceID, dst SurfaceID) DeepError {
+	err := s.NullGraphics.ResolveSurface(src, dst)
+	if err.IsErr {
+		return err
+	}
+	copy(s.surfaceImages[dst].Pix, s.surfaceImages[src].Pix)
+	return err
+}
+
+// Window surfaces are sized from WindowSizes and are never sampled as textures
+func (s *SoftwareGraphics) AddWindowSurface(windowID uint8) (SurfaceID, DeepError) {
+	surfaceID, err := s.NullGraphics.AddWindowSurface(windowID)
+	if err.IsErr {
+		return surfaceID, err
+	}
+	size := s.WindowSizes[windowID]
+	s.surfaceImages = append(s.surfaceImages, image.NewRGBA(image.Rect(0, 0, int(size.X()), int(size.Y()))))
+	s.depthBuffers = append(s.depthBuffers, nil)
+	s.stencils = append(s.stencils, nil)
+	return surfaceID, err
+}
+
+// Returns the surface's image, reallocating it first if a window surface's window was resized
+func (s *SoftwareGraphics) surfaceImage(surfaceID SurfaceID, size IVec2) *image.RGBA {
+	img := s.surfaceImages[surfaceID]
+	if img.Rect.Dx() != int(size.X()) || img.Rect.Dy() != int(size.Y()) {
+		img = image.NewRGBA(image.Rect(0, 0, int(size.X()), int(size.Y())))
+		s.surfaceImages[surfaceID] = img
+	}
+	return img
+}
+
+// Copies the window surface's image to its presented frame, see PresentedPixels. There is no display
+// to wait for, so the swap interval is recorded but presents never block.
+func (s *SoftwareGraphics) PresentSurface(surfaceID SurfaceID) DeepError {
+	err := s.NullGraphics.PresentSurface(surfaceID)
+	if err.IsErr {
+		return err
+	}
+	surface, _ := s.surface("PresentSurface", surfaceID)
+	if !surface.isWindow {
+		return err
+	}
+	img := s.surfaceImage(surfaceID, surface.size)
+	front := s.presented[surfaceID]
+	if front == nil || front.Rect != img.Rect {
+		front = image.NewRGBA(img.Rect)
+		s.presented[surfaceID] = front
+	}
+	copy(front.Pix, img.Pix)
+	return err
+}
+
+// Returns a copy of the frame last presented on the window surface, which is what a window would be
+// showing: draws since that present are not included. Before the first present the image is empty.
+func (s *SoftwareGraphics) PresentedPixels(surfaceID SurfaceID) (image.RGBA, DeepError) {
+	surface, err := s.surface("PresentedPixels", surfaceID)
+	if err.IsErr {
+		return image.RGBA{}, err
+	}
+	if !surface.isWindow {
+		return image.RGBA{}, utils.NewDeepError("[PolyApp] SoftwareGraphics.PresentedPixels(): surface is not a window surface")
+	}
+	front := s.presented[surfaceID]
+	if front == nil {
+		return *image.NewRGBA(image.Rectangle{}), err
+	}
+	result := image.NewRGBA(front.Rect)
+	copy(result.Pix, front.Pix)
+	return *result, err
+}
+
+func (s *SoftwareGraphics) ClearSurface(surfaceID SurfaceID, baseColor ColorFA) DeepError {
+	return s.clearArea("ClearSurface", surfaceID, baseColor, IRect2D{})
+}
+
+func (s *SoftwareGraphics) ClearSurfaceArea(surfaceID SurfaceID, baseColor ColorFA, area IRect2D) DeepError {
+	return s.clearArea("ClearSurfaceArea", surfaceID, baseColor, area)
+}
+
+func (s *SoftwareGraphics) clearArea(method string, surfaceID SurfaceID, baseColor ColorFA, area IRect2D) DeepError {
+	surface, err := s.surface(method, surfaceID)
+	if err.IsErr {
+		return err
+	}
+	s.clearPixels(surfaceID, surface.size, area, baseColor, 1, true, true)
+	s.Log = append(s.Log, NullGraphicsCall{Method: method, SurfaceID: surfaceID})
+	return err
+}
+
+func (s *SoftwareGraphics) ClearSurfaceFull(surfaceID SurfaceID, color ColorFA, depth float32, stencil uint8, clearColor bool, clearDepth bool, clearStencil bool) DeepError {
+	err := s.NullGraphics.ClearSurfaceFull(surfaceID, color, depth, stencil, clearColor, clearDepth, clearStencil)
+	if err.IsErr {
+		return err
+	}
+	surface, _ := s.surface("ClearSurfaceFull", surfaceID)
+	s.clearPixels(surfaceID, surface.size, IRect2D{}, color, depth, clearColor, clearDepth)
+	if clearStencil {
+		mask := s.stencils[surfaceID]
+		for i := range mask {
+			mask[i] = stencil != 0
+		}
+	}
+	return err
+}
+
+// Clears the color and/or depth of the pixels inside area, or the whole surface if area is empty
+func (s *SoftwareGraphics) clearPixels(surfaceID SurfaceID, size IVec2, area IRect2D, color ColorFA, depthValue float32, clearColor bool, clearDepth bool) {
+	area, ok := surfaceArea(size, area)
+	if !ok {
+		return
+	}
+	img, depth := s.surfaceImage(surfaceID, size), s.depthBuffers[surfaceID]
+	if !clearDepth {
+		depth = nil
+	}
+	clear := premultiply(color)
+	for y := area[0].Y(); y < area[1].Y(); y += 1 {
+		for x := area[0].X(); x < area[1].X(); x += 1 {
+			if clearColor {
+				writePixel(img, int(x), int(y), clear)
+			}
+			if depth != nil {
+				depth[y*size.X()+x] = depthValue
+			}
+		}
+	}
+}
+
+func (s *SoftwareGraphics) BeginStencilMask(surfaceID SurfaceID) DeepError {
+	err := s.NullGraphics.BeginStencilMask(surfaceID)
+	if err.IsErr {
+		return err
+	}
+	surface, _ := s.surface("BeginStencilMask", surfaceID)
+	s.stencils[surfaceID] = make([]bool, surface.size.X()*surface.size.Y())
+	return err
+}
+
+func (s *SoftwareGraphics) ReadSurfacePixels(surfaceID SurfaceID, area IRect2D) (image.RGBA, DeepError) {
+	surface, err := s.surface("ReadSurfacePixels", surfaceID)
+	if err.IsErr {
+		return image.RGBA{}, err
+	}
+	area, ok := surfaceArea(surface.size, area)
+	if !ok {
+		return *image.NewRGBA(image.Rectangle{}), err
+	}
+	rect := image.Rect(int(area[0].X()), int(area[0].Y()), int(area[1].X()), int(area[1].Y()))
+	result := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(result, result.Rect, s.surfaceImage(surfaceID, surface.size), rect.Min, draw.Src)
+	return *result, err
+}
+
+// Returns area intersected with the surface bounds, or the whole surface if area is empty
+func surfaceArea(size IVec2, area IRect2D) (IRect2D, bool) {
+	bounds := IRect2D{{0, 0}, size}
+	if area.W() <= 0 || area.H() <= 0 {
+		return bounds, size.X() > 0 && size.Y() > 0
+	}
+	return IntersectIRect2D(area, bounds)
+}
+
+func (s *SoftwareGraphics) DrawBatch(batchID BatchID, surfaceID SurfaceID, rendererID RendererID, forceRedraw bool) DeepError {
+	clip, ok := s.takeDirtyRegion(batchID, forceRedraw)
+	if !ok {
+		return nullOk()
+	}
+	return s.drawBatch("DrawBatch", batchID, surfaceID, rendererID, clip, nil)
+}
+
+func (s *SoftwareGraphics) DrawBatchClipped(batchID BatchID, surfaceID SurfaceID, rendererID RendererID, clip IRect2D, forceRedraw bool) DeepError {
+	dirty, ok := s.takeDirtyRegion(batchID, forceRedraw)
+	if dirty.W() > 0 && dirty.H() > 0 {
+		clip, ok = IntersectIRect2D(clip, dirty)
+	}
+	if !ok || clip.W() <= 0 || clip.H() <= 0 {
+		return nullOk()
+	}
+	return s.drawBatch("DrawBatchClipped", batchID, surfaceID, rendererID, clip, nil)
+}
+
+func (s *SoftwareGraphics) DrawInstanced(shape InstancedShape, surfaceID SurfaceID, rendererID RendererID, instanceCount uint32) DeepError {
+	if instanceCount > shape.MaxInstances {
+		return utils.NewDeepError("[PolyApp] SoftwareGraphics.DrawInstanced(): instance count is larger than the instanced shape")
+	}
+	return s.drawBatch("DrawInstanced", shape.BatchID, surfaceID, rendererID, IRect2D{}, &softwareInstances{shape: shape, count: instanceCount})
+}
+
+type softwareInstances struct {
+	shape InstancedShape
+	count uint32
+}
+
+// A vertex after projection, in pixel coordinates with its perspective divisor kept for interpolation
+type rasterVertex struct {
+	x, y, z float32
+	invW    float32
+	color   ColorFA
+	uv      Vec2
+}
+
+func (s *SoftwareGraphics) drawBatch(method string, batchID BatchID, surfaceID SurfaceID, rendererID RendererID, clip IRect2D, instances *softwareInstances) DeepError {
+	s.uploadPendingTextures()
+	dErr := s.draw(method, batchID, surfaceID, rendererID, clip)
+	if dErr.IsErr {
+		return dErr
+	}
+	batch, renderer := s.batches[batchID], s.renderers[rendererID]
+	surface, _ := s.surface(method, surfaceID)
+	var primSize int
+	switch batch.flags & DrawMask {
+	case Tris:
+		primSize = 3
+	case Lines:
+		primSize = 2
+	case Pixels:
+		primSize = 1
+	default:
+		return utils.NewDeepError("[PolyApp] SoftwareGraphics." + method + "(): batch draw mode cannot be rasterized")
+	}
+	area, ok := surfaceArea(surface.size, clip)
+	if ok {
+		area, ok = IntersectIRect2D(area, surface.viewportRect())
+	}
+	if !ok || area.W() <= 0 || area.H() <= 0 {
+		return dErr
+	}
+	viewport := surface.viewportRect()
+	size := Vec2{float32(viewport.W()), float32(viewport.H())}
+	origin := Vec2{float32(viewport[0].X()), float32(viewport[0].Y())}
+	viewProj := renderer.camera.ViewProjection(size, s.XRightYUpZAway())
+	var texture *image.RGBA
+	if batch.flags&TexMask == HasTex {
+		texture = s.textureImages[batch.textureID]
+	}
+	shapes := make([]nullShape, 0, len(batch.shapes))
+	for _, shape := range batch.shapes {
+		if !shape.hidden && (instances == nil || shape.shape == instances.shape.BatchShape) {
+			shapes = append(shapes, shape)
+		}
+	}
+	sort.SliceStable(shapes, func(i, j int) bool { return shapes[i].layer < shapes[j].layer })
+	target := softwareTarget{
+		img:       s.surfaceImage(surfaceID, surface.size),
+		depth:     s.depthBuffers[surfaceID],
+		width:     surface.size.X(),
+		area:      area,
+		blend:     batch.blend,
+		texture:   texture,
+		premult:   s.premultiplied[batch.textureID],
+		renderer:  renderer,
+		stencil:   s.stencils[surfaceID],
+		mask:      surface.stencil,
+		writeMask: s.masking && s.maskSurface == surfaceID,
+	}
+	if int32(len(target.stencil)) != surface.size.X()*surface.size.Y() {
+		// the window was resized since the mask was drawn
+		target.stencil, target.mask = nil, stencilNone
+	}
+	instanceCount := uint32(1)
+	if instances != nil {
+		instanceCount = instances.count
+	}
+	for _, shape := range shapes {
+		indexes := batch.indexes[shape.shape.IndexZone.Start:shape.shape.IndexZone.End]
+		vertices := batch.vertices[shape.shape.VertexZone.Start:shape.shape.VertexZone.End]
+		for inst := uint32(0); inst < instanceCount; inst += 1 {
+			transform, tint := viewProj.Mul(shape.transform), batch.tint
+			if instances != nil {
+				instance := batch.instances[instances.shape.InstanceZone.Start+inst]
+				transform = transform.Mul(instance.transform)
+				tint = multiplyColor(tint, instance.color)
+			}
+			// shapes without indexes (and every Pixels shape) draw their vertices in order
+			sequential := len(indexes) == 0
+			primCount := len(indexes) / primSize
+			if sequential {
+				primCount = len(vertices) / primSize
+			}
+			for i := 0; i < primCount*primSize; i += primSize {
+				prim := make([]rasterVertex, primSize)
+				visible := true
+				for c := range prim {
+					vertIndex := i + c
+					if !sequential {
+						vertIndex = int(indexes[i+c])
+					}
+					vert := storedVertex(batch.flags, vertices[vertIndex])
+					var ok bool
+					prim[c], ok = projectVertex(vert, transform, origin, size, batch.flags, tint)
+					if renderer.srgb {
+						prim[c].color = SRGBToLinear(prim[c].color)
+					}
+					visible = visible && ok
+				}
+				if !visible {
+					continue
+				}
+				switch primSize {
+				case 3:
+					target.fillTriangle([3]rasterVertex{prim[0], prim[1], prim[2]})
+				case 2:
+					target.drawLine(prim[0], prim[1])
+				default:
+					target.drawPoint(prim[0].x, prim[0].y, prim, [3]float32{1})
+				}
+			}
+		}
+	}
+	return dErr
+}
+
+// Projects the vertex into pixels of the viewport whose top-left corner is at origin
+func projectVertex(vert Vertex, transform Mat4, origin Vec2, size Vec2, flags VertexFlags, tint ColorFA) (rasterVertex, bool) {
+	clip := transform.Transform(Vec4{vert.Pos.X(), vert.Pos.Y(), vert.Pos.Z(), 1})
+	if clip.W() <= 0 {
+		return rasterVertex{}, false
+	}
+	invW := 1 / clip.W()
+	color := tint
+	if flags&ColMask != NoCol {
+		color = multiplyColor(vert.Color, tint)
+	}
+	return rasterVertex{
+		x:     origin.X() + (clip.X()*invW+1)/2*size.X(),
+		y:     origin.Y() + (1-clip.Y()*invW)/2*size.Y(),
+		z:     (clip.Z()*invW + 1) / 2,
+		invW:  invW,
+		color: color,
+		uv:    vert.UV,
+	}, true
+}
+
+type softwareTarget struct {
+	img      *image.RGBA
+	depth    []float32
+	width    int32
+	area     IRect2D
+	blend    BlendMode
+	texture  *image.RGBA
+	premult  bool // set for textures added with Texture.Premultiply, whose texels are blended premultiplied
+	renderer *nullRenderer
+	stencil  []bool
+	mask     nullStencil
+	// set while drawing a stencil mask, pixels are marked in stencil instead of shaded
+	writeMask bool
+}
+
+func (t softwareTarget) fillTriangle(tri [3]rasterVertex) {
+	a, b, c := tri[0], tri[1], tri[2]
+	area := edge(a.x, a.y, b.x, b.y, c.x, c.y)
+	if area == 0 || t.culled(area) {
+		return
+	}
+	minX := int32(math.Max(math.Floor(math.Min(a.x, math.Min(b.x, c.x))), float32(t.area[0].X())))
+	maxX := int32(math.Min(math.Ciel(math.Max(a.x, math.Max(b.x, c.x))), float32(t.area[1].X())))
+	minY := int32(math.Max(math.Floor(math.Min(a.y, math.Min(b.y, c.y))), float32(t.area[0].Y())))
+	maxY := int32(math.Min(math.Ciel(math.Max(a.y, math.Max(b.y, c.y))), float32(t.area[1].Y())))
+	for y := minY; y < maxY; y += 1 {
+		for x := minX; x < maxX; x += 1 {
+			px, py := float32(x)+0.5, float32(y)+0.5
+			// barycentric weights, the sign of area makes both windings fill
+			wa := edge(b.x, b.y, c.x, c.y, px, py) / area
+			wb := edge(c.x, c.y, a.x, a.y, px, py) / area
+			wc := edge(a.x, a.y, b.x, b.y, px, py) / area
+			if wa < 0 || wb < 0 || wc < 0 {
+				continue
+			}
+			t.shadePixel(x, y, tri[:], [3]float32{wa, wb, wc})
+		}
+	}
+}
+
+// Reports whether the renderer's cull mode skips a triangle of the given screen space signed area
+func (t softwareTarget) culled(area float32) bool {
+	if t.renderer.cullMode == CullNone {
+		return false
+	}
+	// screen Y runs down, so triangles that appear counter-clockwise have a negative area
+	front := (area < 0) != t.renderer.frontCW
+	return front == (t.renderer.cullMode == CullFront)
+}
+
+// Draws a 1 pixel line from a to b, one pixel per step along its longest axis
+func (t softwareTarget) drawLine(a rasterVertex, b rasterVertex) {
+	dx, dy := b.x-a.x, b.y-a.y
+	steps := math.Ciel(math.Max(math.Abs(dx), math.Abs(dy)))
+	for k := float32(0); k <= steps; k += 1 {
+		ratio := float32(0)
+		if steps > 0 {
+			ratio = k / steps
+		}
+		t.drawPoint(a.x+dx*ratio, a.y+dy*ratio, []rasterVertex{a, b}, [3]float32{1 - ratio, ratio})
+	}
+}
+
+// Draws the pixel containing (px, py) if it is inside the target area
+func (t softwareTarget) drawPoint(px float32, py float32, verts []rasterVertex, weights [3]float32) {
+	x, y := int32(math.Floor(px)), int32(math.Floor(py))
+	if x < t.area[0].X() || x >= t.area[1].X() || y < t.area[0].Y() || y >= t.area[1].Y() {
+		return
+	}
+	t.shadePixel(x, y, verts, weights)
+}
+
+// Depth tests, shades and blends one pixel, where weights are the screen space
+// weights of each of verts at the pixel
+func (t softwareTarget) shadePixel(x int32, y int32, verts []rasterVertex, weights [3]float32) {
+	if t.stencil != nil {
+		i := y*t.width + x
+		switch {
+		case t.writeMask:
+			t.stencil[i] = true
+			return
+		case t.mask == stencilKeepInside && !t.stencil[i], t.mask == stencilKeepOutside && t.stencil[i]:
+			return
+		}
+	}
+	z := float32(0)
+	for i, v := range verts {
+		z += weights[i] * v.z
+	}
+	if !t.depthPasses(x, y, z) {
+		return
+	}
+	// perspective correct interpolation weights
+	sum := float32(0)
+	for i, v := range verts {
+		weights[i] *= v.invW
+		sum += weights[i]
+	}
+	var color ColorFA
+	uv := ZeroVec2
+	for i, v := range verts {
+		w := weights[i] / sum
+		for ch := range color {
+			color[ch] += w * v.color[ch]
+		}
+		uv = uv.Add(v.uv.Scale(w))
+	}
+	if t.texture != nil {
+		texel := sampleTexture(t.texture, uv)
+		if t.renderer.srgb {
+			texel = SRGBToLinear(texel)
+		}
+		if t.premult {
+			texel = premultiply(texel)
+		}
+		color = multiplyColor(color, texel)
+	}
+	t.blendPixel(int(x), int(y), color)
+}
+
+// Twice the signed area of the triangle (ax, ay), (bx, by), (px, py)
+func edge(ax float32, ay float32, bx float32, by float32, px float32, py float32) float32 {
+	return (bx-ax)*(py-ay) - (by-ay)*(px-ax)
+}
+
+func (t softwareTarget) depthPasses(x int32, y int32, z float32) bool {
+	if t.depth == nil || !t.renderer.depthTest {
+		return true
+	}
+	i := y*t.width + x
+	old := t.depth[i]
+	pass := false
+	switch t.renderer.depthFunc {
+	case DepthLess:
+		pass = z < old
+	case DepthLessEqual:
+		pass = z <= old
+	case DepthEqual:
+		pass = z == old
+	case DepthNotEqual:
+		pass = z != old
+	case DepthGreater:
+		pass = z > old
+	case DepthGreaterEqual:
+		pass = z >= old
+	case DepthAlways:
+		pass = true
+	}
+	if pass && t.renderer.depthWrite {
+		t.depth[i] = z
+	}
+	return pass
+}
+
+func (t softwareTarget) blendPixel(x int, y int, src ColorFA) {
+	dst := readPixel(t.img, x, y)
+	if t.renderer.srgb {
+		dst = premultiply(SRGBToLinear(unpremultiply(dst)))
+	}
+	var out ColorFA
+	switch t.blend {
+	case BlendNone:
+		out = premultiply(src)
+	case BlendAdditive:
+		p := premultiply(src)
+		out = ColorFA{p[0] + dst[0], p[1] + dst[1], p[2] + dst[2], dst[3]}
+	case BlendMultiply:
+		out = ColorFA{src[0] * dst[0], src[1] * dst[1], src[2] * dst[2], dst[3]}
+	case BlendPremultipliedAlpha:
+		out = ColorFA{src[0] + dst[0]*(1-src[3]), src[1] + dst[1]*(1-src[3]), src[2] + dst[2]*(1-src[3]), src[3] + dst[3]*(1-src[3])}
+	default:
+		p := premultiply(src)
+		out = ColorFA{p[0] + dst[0]*(1-src[3]), p[1] + dst[1]*(1-src[3]), p[2] + dst[2]*(1-src[3]), p[3] + dst[3]*(1-src[3])}
+	}
+	if t.renderer.srgb {
+		out = premultiply(LinearToSRGB(unpremultiply(out)))
+	}
+	writePixel(t.img, x, y, out)
+}
+
+// Returns the straight alpha color of the texel at uv, clamping to the texture edges
+func sampleTexture(texture *image.RGBA, uv Vec2) ColorFA {
+	w, h := texture.Rect.Dx(), texture.Rect.Dy()
+	if w == 0 || h == 0 {
+		return ColorFA{1, 1, 1, 1}
+	}
+	x := math.Clamp(0, int(uv.X()*float32(w)), w-1)
+	y := math.Clamp(0, int(uv.Y()*float32(h)), h-1)
+	p := readPixel(texture, x+texture.Rect.Min.X, y+texture.Rect.Min.Y)
+	return unpremultiply(p)
+}
+
+func multiplyColor(a ColorFA, b ColorFA) ColorFA {
+	return ColorFA{a[0] * b[0], a[1] * b[1], a[2] * b[2], a[3] * b[3]}
+}
+
+func premultiply(c ColorFA) ColorFA {
+	return ColorFA{c[0] * c[3], c[1] * c[3], c[2] * c[3], c[3]}
+}
+
+func unpremultiply(c ColorFA) ColorFA {
+	if c[3] == 0 {
+		return ColorFA{}
+	}
+	return ColorFA{c[0] / c[3], c[1] / c[3], c[2] / c[3], c[3]}
+}
+
+// Reads an alpha premultiplied pixel as floats
+func readPixel(img *image.RGBA, x int, y int) ColorFA {
+	i := img.PixOffset(x, y)
+	return ColorFA{float32(img.Pix[i]) / 255, float32(img.Pix[i+1]) / 255, float32(img.Pix[i+2]) / 255, float32(img.Pix[i+3]) / 255}
+}
+
+// Writes an alpha premultiplied pixel from floats
+func writePixel(img *image.RGBA, x int, y int, c ColorFA) {
+	i := img.PixOffset(x, y)
+	for ch := 0; ch < 4; ch += 1 {
+		img.Pix[i+ch] = uint8(math.Clamp(0, c[ch], 1)*255 + 0.5)
+	}
+}
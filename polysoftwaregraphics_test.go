@@ -0,0 +1,532 @@
+package polyapp
+
+import (
+	"fmt"
+	"testing"
+
+	math "github.com/gabe-lee/genmath"
+)
+
+var testAxes = Vec3{1, 1, 1}
+
+func newTestSoftware(t testing.TB, axes Vec3, size IVec2, withDepth bool) (GraphicsProvider, SurfaceID) {
+	t.Helper()
+	g := GraphicsProvider{GraphicsInterface: NewSoftwareGraphics(axes)}
+	var surfaceID SurfaceID
+	var err DeepError
+	if withDepth {
+		surfaceID, _, err = g.AddDrawSurfaceWithDepth(size, 0)
+	} else {
+		surfaceID, _, err = g.AddDrawSurface(size, 0)
+	}
+	mustOk(t, err)
+	mustOk(t, g.ClearSurface(surfaceID, ColorFA{0, 0, 0, 0}))
+	return g, surfaceID
+}
+
+func mustOk(t testing.TB, err DeepError) {
+	t.Helper()
+	if err.IsErr {
+		t.Fatal(err.Error())
+	}
+}
+
+func testPixel(t testing.TB, g GraphicsProvider, surfaceID SurfaceID, x int32, y int32) [4]uint8 {
+	t.Helper()
+	img, err := g.ReadSurfacePixels(surfaceID, IRect2D{{x, y}, {x + 1, y + 1}})
+	mustOk(t, err)
+	return [4]uint8{img.Pix[0], img.Pix[1], img.Pix[2], img.Pix[3]}
+}
+
+var (
+	testRed   = ColorFA{1, 0, 0, 1}
+	testGreen = ColorFA{0, 1, 0, 1}
+	testBlue  = ColorFA{0, 0, 1, 1}
+)
+
+// Adds a square of the given half size centered on center, facing -Z
+func addTestSquare3D(t testing.TB, g GraphicsProvider, batchID BatchID, center Vec3, half float32, color ColorFA) BatchShape {
+	t.Helper()
+	x, y, z := center.X(), center.Y(), center.Z()
+	shape, err := g.AddShapeWithVertices(batchID, quadPrototype(), []Vertex{
+		{Pos: Vec3{x - half, y - half, z}, Color: color},
+		{Pos: Vec3{x + half, y - half, z}, Color: color},
+		{Pos: Vec3{x + half, y + half, z}, Color: color},
+		{Pos: Vec3{x - half, y + half, z}, Color: color},
+	})
+	mustOk(t, err)
+	return shape
+}
+
+func TestDepthTestNearerSquareWins(t *testing.T) {
+	for _, nearFirst := range []bool{true, false} {
+		g, surfaceID := newTestSoftware(t, testAxes, IVec2{16, 16}, true)
+		rendererID, err := g.AddRenderer(Pos3D|ColFA|Cam3D, nil)
+		mustOk(t, err)
+		mustOk(t, g.SetCamera3D(rendererID, Vec3{0, 0, -5}, Vec3{0, 0, 0}, Vec3{0, 1, 0}, 60, 0.1, 100))
+		mustOk(t, g.SetRendererDepthTest(rendererID, true, true, DepthLess))
+		batchID, err := g.AddDrawBatch(Pos3D|ColFA|Cam3D, 0, 8)
+		mustOk(t, err)
+		if nearFirst {
+			addTestSquare3D(t, g, batchID, Vec3{0, 0, 0}, 1, testRed)
+			addTestSquare3D(t, g, batchID, Vec3{0, 0, 1}, 2, testBlue)
+		} else {
+			addTestSquare3D(t, g, batchID, Vec3{0, 0, 1}, 2, testBlue)
+			addTestSquare3D(t, g, batchID, Vec3{0, 0, 0}, 1, testRed)
+		}
+		mustOk(t, g.DrawBatch(batchID, surfaceID, rendererID, true))
+		if got := testPixel(t, g, surfaceID, 8, 8); got != [4]uint8{255, 0, 0, 255} {
+			t.Errorf("nearFirst=%v: center pixel = %v, want the nearer red square", nearFirst, got)
+		}
+		if got := testPixel(t, g, surfaceID, 4, 8); got != [4]uint8{0, 0, 255, 255} {
+			t.Errorf("nearFirst=%v: edge pixel = %v, want the larger blue square", nearFirst, got)
+		}
+	}
+}
+
+func TestDrawInstancedDrawsEachInstance(t *testing.T) {
+	g, surfaceID := newTestSoftware(t, testAxes, IVec2{16, 16}, false)
+	rendererID, err := g.AddRenderer(Pos2D|ColFA|Instanced, nil)
+	mustOk(t, err)
+	batchID, err := g.AddDrawBatch(Pos2D|ColFA|Instanced, 0, 4)
+	mustOk(t, err)
+	shape, err := g.AddInstancedShape(batchID, quadPrototype(), 2)
+	mustOk(t, err)
+	mustOk(t, g.UpdateQuad2D(shape.BatchShape, Rect2D{{-0.25, -0.25}, {0.25, 0.25}}.Quad(), ColorFA{1, 1, 1, 1}, Quad2D{}, NoExtra))
+	mustOk(t, g.SetInstanceTransform(shape, 0, Translate3D(Vec3{-0.5, 0, 0}), testRed))
+	mustOk(t, g.SetInstanceTransform(shape, 1, Translate3D(Vec3{0.5, 0, 0}), testGreen))
+	if err := g.SetInstanceTransform(shape, 2, IdentityMat4, testRed); !err.IsErr {
+		t.Error("SetInstanceTransform past MaxInstances returned no error")
+	}
+
+	mustOk(t, g.DrawInstanced(shape, surfaceID, rendererID, 1))
+	if got := testPixel(t, g, surfaceID, 4, 8); got != [4]uint8{255, 0, 0, 255} {
+		t.Errorf("first instance pixel = %v, want red", got)
+	}
+	if got := testPixel(t, g, surfaceID, 12, 8); got != [4]uint8{} {
+		t.Errorf("second instance pixel = %v, want it undrawn with an instance count of 1", got)
+	}
+	mustOk(t, g.DrawInstanced(shape, surfaceID, rendererID, 2))
+	if got := testPixel(t, g, surfaceID, 12, 8); got != [4]uint8{0, 255, 0, 255} {
+		t.Errorf("second instance pixel = %v, want green", got)
+	}
+	if err := g.DrawInstanced(shape, surfaceID, rendererID, 3); !err.IsErr {
+		t.Error("DrawInstanced past MaxInstances returned no error")
+	}
+}
+
+const benchmarkInstanceCount = 10000
+
+func benchmarkInstanceOffset(i int, frame int) Vec3 {
+	return Vec3{float32(i%100)/50 - 1, float32(i/100)/50 - 1 + float32(frame%10)/1000, 0}
+}
+
+func BenchmarkDrawInstanced(b *testing.B) {
+	g, surfaceID := newTestSoftware(b, testAxes, IVec2{128, 128}, false)
+	rendererID, err := g.AddRenderer(Pos2D|ColFA|Instanced, nil)
+	mustOk(b, err)
+	batchID, err := g.AddDrawBatch(Pos2D|ColFA|Instanced, 0, 4)
+	mustOk(b, err)
+	shape, err := g.AddInstancedShape(batchID, quadPrototype(), benchmarkInstanceCount)
+	mustOk(b, err)
+	mustOk(b, g.UpdateQuad2D(shape.BatchShape, Rect2D{{0, 0}, {0.01, 0.01}}.Quad(), ColorFA{1, 1, 1, 1}, Quad2D{}, NoExtra))
+	b.ResetTimer()
+	for frame := 0; frame < b.N; frame += 1 {
+		for i := 0; i < benchmarkInstanceCount; i += 1 {
+			mustOk(b, g.SetInstanceTransform(shape, uint32(i), Translate3D(benchmarkInstanceOffset(i, frame)), testRed))
+		}
+		mustOk(b, g.DrawInstanced(shape, surfaceID, rendererID, benchmarkInstanceCount))
+	}
+}
+
+func BenchmarkDrawPerShapeQuads(b *testing.B) {
+	g, surfaceID := newTestSoftware(b, testAxes, IVec2{128, 128}, false)
+	rendererID, err := g.AddRenderer(Pos2D|ColFA, nil)
+	mustOk(b, err)
+	batchID, err := g.AddDrawBatch(Pos2D|ColFA, 0, benchmarkInstanceCount*4)
+	mustOk(b, err)
+	shapes := make([]BatchShape, benchmarkInstanceCount)
+	for i := range shapes {
+		shapes[i], err = g.AddQuad2D(batchID, Quad2D{}, testRed, Quad2D{}, NoExtra)
+		mustOk(b, err)
+	}
+	b.ResetTimer()
+	for frame := 0; frame < b.N; frame += 1 {
+		for i, shape := range shapes {
+			min := benchmarkInstanceOffset(i, frame).AsVec2()
+			quad := Rect2D{min, min.Add(Vec2{0.01, 0.01})}.Quad()
+			mustOk(b, g.UpdateQuad2D(shape, quad, testRed, Quad2D{}, NoExtra))
+		}
+		mustOk(b, g.DrawBatch(batchID, surfaceID, rendererID, true))
+	}
+}
+
+// Adds a 2x2 texture with red, green, blue and white texels, in reading order from the top-left
+func addTestQuadrantTexture(t testing.TB, g GraphicsProvider) TextureID {
+	t.Helper()
+	textureID, err := g.AddTexture(&Texture{
+		Data: []byte{255, 0, 0, 255, 0, 255, 0, 255, 0, 0, 255, 255, 255, 255, 255, 255},
+		Size: IVec2{2, 2},
+	})
+	mustOk(t, err)
+	return textureID
+}
+
+// Checks that the quadrants of the texture from addTestQuadrantTexture cover rect of the surface the right way up
+func checkTestQuadrants(t testing.TB, g GraphicsProvider, surfaceID SurfaceID, rect IRect2D, context string) {
+	t.Helper()
+	x0, y0, x1, y1 := rect[0].X()+1, rect[0].Y()+1, rect[1].X()-2, rect[1].Y()-2
+	for _, want := range []struct {
+		x, y  int32
+		pixel [4]uint8
+	}{
+		{x0, y0, [4]uint8{255, 0, 0, 255}},
+		{x1, y0, [4]uint8{0, 255, 0, 255}},
+		{x0, y1, [4]uint8{0, 0, 255, 255}},
+		{x1, y1, [4]uint8{255, 255, 255, 255}},
+	} {
+		if got := testPixel(t, g, surfaceID, want.x, want.y); got != want.pixel {
+			t.Errorf("%s: pixel (%d, %d) = %v, want %v", context, want.x, want.y, got, want.pixel)
+		}
+	}
+}
+
+func TestBlitTextureOrientation(t *testing.T) {
+	for _, axes := range []Vec3{{1, 1, 1}, {1, -1, 1}} {
+		g, surfaceID := newTestSoftware(t, axes, IVec2{16, 16}, false)
+		rendererID, err := g.AddRenderer(Pos2D|HasTex, nil)
+		mustOk(t, err)
+		textureID := addTestQuadrantTexture(t, g)
+		dest := IRect2D{{0, 0}, {8, 8}}
+		mustOk(t, g.BlitTexture(textureID, surfaceID, dest, Rect2D{{0, 0}, {1, 1}}, rendererID))
+		checkTestQuadrants(t, g, surfaceID, dest, fmt.Sprintf("axes %v BlitTexture", axes))
+		if got := testPixel(t, g, surfaceID, 12, 12); got != [4]uint8{} {
+			t.Errorf("axes %v BlitTexture: pixel outside destRect = %v, want it untouched", axes, got)
+		}
+
+		g, surfaceID = newTestSoftware(t, axes, IVec2{16, 16}, false)
+		rendererID, err = g.AddRenderer(Pos2D|HasTex, nil)
+		mustOk(t, err)
+		textureID = addTestQuadrantTexture(t, g)
+		mustOk(t, g.DrawFullscreenTexture(textureID, surfaceID, rendererID))
+		checkTestQuadrants(t, g, surfaceID, IRect2D{{0, 0}, {16, 16}}, fmt.Sprintf("axes %v DrawFullscreenTexture", axes))
+
+		g, surfaceID = newTestSoftware(t, axes, IVec2{16, 16}, false)
+		rendererID, err = g.AddRenderer(Pos2D|HasTex, nil)
+		mustOk(t, err)
+		textureID = addTestQuadrantTexture(t, g)
+		chain, err := NewPostProcessChain(g, IVec2{16, 16})
+		mustOk(t, err)
+		chain.AddPass(rendererID)
+		chain.AddPass(rendererID)
+		chain.AddPass(rendererID)
+		mustOk(t, chain.Process(textureID, surfaceID))
+		checkTestQuadrants(t, g, surfaceID, IRect2D{{0, 0}, {16, 16}}, fmt.Sprintf("axes %v PostProcessChain", axes))
+	}
+}
+
+func TestCoordinateConventionCornerRect(t *testing.T) {
+	tests := []struct {
+		name       string
+		convention CoordinateConvention
+		cam2D      bool
+		rect       Rect2D
+	}{
+		// each rect should cover the top-left 4x4 pixels of a 16x16 surface
+		{"YUpOriginCenter", YUpOriginCenter, false, Rect2D{{-1, 0.5}, {-0.5, 1}}},
+		{"YDownOriginTopLeft", YDownOriginTopLeft, false, Rect2D{{0, 0}, {4, 4}}},
+		{"YDownOriginTopLeft Cam2D", YDownOriginTopLeft, true, Rect2D{{10, 20}, {14, 24}}},
+	}
+	for _, axes := range []Vec3{{1, 1, 1}, {1, -1, 1}} {
+		for _, tt := range tests {
+			g, surfaceID := newTestSoftware(t, axes, IVec2{16, 16}, false)
+			flags := Pos2D | ColFA
+			if tt.cam2D {
+				flags |= Cam2D
+			}
+			rendererID, err := g.AddRenderer(flags, nil)
+			mustOk(t, err)
+			mustOk(t, g.SetRendererCoordinateConvention(rendererID, tt.convention))
+			if tt.cam2D {
+				mustOk(t, g.SetCamera2D(rendererID, Vec2{10, 20}, 1, 0))
+			}
+			batchID, err := g.AddDrawBatch(flags, 0, 4)
+			mustOk(t, err)
+			_, err = g.AddRect2D(batchID, tt.rect, testRed, Rect2D{}, NoExtra)
+			mustOk(t, err)
+			mustOk(t, g.DrawBatch(batchID, surfaceID, rendererID, true))
+			for _, p := range [][2]int32{{0, 0}, {3, 0}, {0, 3}, {3, 3}} {
+				if got := testPixel(t, g, surfaceID, p[0], p[1]); got != [4]uint8{255, 0, 0, 255} {
+					t.Errorf("axes %v %s: pixel %v = %v, want red", axes, tt.name, p, got)
+				}
+			}
+			for _, p := range [][2]int32{{4, 0}, {0, 4}, {15, 15}, {0, 15}} {
+				if got := testPixel(t, g, surfaceID, p[0], p[1]); got != [4]uint8{} {
+					t.Errorf("axes %v %s: pixel %v = %v, want it untouched", axes, tt.name, p, got)
+				}
+			}
+		}
+	}
+}
+
+func TestPremultipliedTextureDraw(t *testing.T) {
+	for _, premultiply := range []bool{false, true} {
+		g, surfaceID := newTestSoftware(t, testAxes, IVec2{4, 4}, false)
+		mustOk(t, g.ClearSurface(surfaceID, ColorFA{0, 0, 1, 1}))
+		textureID, err := g.AddTexture(&Texture{Data: []byte{255, 0, 0, 128}, Size: IVec2{1, 1}, Premultiply: premultiply})
+		mustOk(t, err)
+		rendererID, err := g.AddRenderer(Pos2D|HasTex|ColFA, nil)
+		mustOk(t, err)
+		batchID, err := g.AddDrawBatch(Pos2D|HasTex|ColFA, textureID, 4)
+		mustOk(t, err)
+		wantBlend := BlendAlpha
+		if premultiply {
+			wantBlend = BlendPremultipliedAlpha
+		}
+		if blend := g.GraphicsInterface.(*SoftwareGraphics).batches[batchID].blend; blend != wantBlend {
+			t.Errorf("premultiply=%v: batch blend mode %v, want %v", premultiply, blend, wantBlend)
+		}
+		_, err = g.AddRect2D(batchID, Rect2D{{-1, -1}, {1, 1}}, ColorFA{1, 1, 1, 1}, Rect2D{{0, 0}, {1, 1}}, NoExtra)
+		mustOk(t, err)
+		mustOk(t, g.DrawBatch(batchID, surfaceID, rendererID, true))
+		// half red over blue either way
+		if got := testPixel(t, g, surfaceID, 2, 2); got != [4]uint8{128, 0, 127, 255} {
+			t.Errorf("premultiply=%v: pixel = %v, want [128 0 127 255]", premultiply, got)
+		}
+	}
+}
+
+// Returns which of the faces of the cube from testCube, colored by faceColors, show on the surface
+func visibleTestFaces(t testing.TB, g GraphicsProvider, surfaceID SurfaceID, faceColors []ColorFA) [6]bool {
+	t.Helper()
+	size, err := g.GetSurfaceSize(surfaceID)
+	mustOk(t, err)
+	img, err := g.ReadSurfacePixels(surfaceID, IRect2D{{0, 0}, size})
+	mustOk(t, err)
+	var visible [6]bool
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		for face, color := range faceColors {
+			if [3]uint8{img.Pix[i], img.Pix[i+1], img.Pix[i+2]} == [3]uint8{uint8(color[0] * 255), uint8(color[1] * 255), uint8(color[2] * 255)} && img.Pix[i+3] == 255 {
+				visible[face] = true
+			}
+		}
+	}
+	return visible
+}
+
+func TestCullModeCube(t *testing.T) {
+	faceColors := []ColorFA{{1, 0, 0, 1}, {0, 1, 0, 1}, {0, 0, 1, 1}, {1, 1, 0, 1}, {0, 1, 1, 1}, {1, 0, 1, 1}}
+	for _, axes := range []Vec3{{1, 1, 1}, {1, 1, -1}} {
+		normals, vertices, indices := testCube(axes)
+		for i := range vertices {
+			vertices[i].Color = faceColors[i/4]
+		}
+		camera := Vec3{-4, 5, -6}
+		// a face is seen from outside when the camera is on the side its normal points to
+		var front [6]bool
+		for face, n := range normals {
+			front[face] = n.Dot(camera) > 1
+		}
+		for _, tt := range []struct {
+			mode       CullMode
+			windingCCW bool
+			wantFront  bool
+		}{
+			{CullBack, true, true},
+			{CullFront, true, false},
+			// with the winding flipped the cube's outside counts as its back
+			{CullBack, false, false},
+			{CullFront, false, true},
+		} {
+			g, surfaceID := newTestSoftware(t, axes, IVec2{64, 64}, false)
+			rendererID, err := g.AddRenderer(Pos3D|ColFA|Cam3D, nil)
+			mustOk(t, err)
+			mustOk(t, g.SetCamera3D(rendererID, camera, Vec3{0, 0, 0}, Vec3{0, 1, 0}, 60, 0.1, 100))
+			mustOk(t, g.SetRendererCullMode(rendererID, tt.mode))
+			mustOk(t, g.SetRendererFrontFace(rendererID, tt.windingCCW))
+			batchID, err := g.AddDrawBatch(Pos3D|ColFA|Cam3D, 0, 24)
+			mustOk(t, err)
+			_, err = g.AddShapeWithVertices(batchID, ShapePrototype{VertCount: 24, IndexCount: 36, Indexes: indices}, vertices)
+			mustOk(t, err)
+			mustOk(t, g.DrawBatch(batchID, surfaceID, rendererID, true))
+			visible := visibleTestFaces(t, g, surfaceID, faceColors)
+			count := 0
+			for face := range visible {
+				if visible[face] {
+					count += 1
+				}
+				if visible[face] != (front[face] == tt.wantFront) {
+					t.Errorf("axes %v cull mode %d ccw=%v: face %v visible = %v", axes, tt.mode, tt.windingCCW, normals[face], visible[face])
+				}
+			}
+			if count != 3 {
+				t.Errorf("axes %v cull mode %d ccw=%v: %d faces visible, want 3", axes, tt.mode, tt.windingCCW, count)
+			}
+		}
+	}
+}
+
+func TestBlitTextureLetterboxed(t *testing.T) {
+	for _, size := range []IVec2{{32, 16}, {16, 32}} {
+		for _, fullscreen := range []bool{false, true} {
+			name := fmt.Sprintf("surface %v fullscreen=%v", size, fullscreen)
+			g, surfaceID := newTestSoftware(t, testAxes, size, false)
+			viewport := ComputeLetterbox(size, 1)
+			mustOk(t, g.SetViewport(surfaceID, viewport))
+			rendererID, err := g.AddRenderer(Pos2D|HasTex, nil)
+			mustOk(t, err)
+			textureID := addTestQuadrantTexture(t, g)
+			if fullscreen {
+				mustOk(t, g.DrawFullscreenTexture(textureID, surfaceID, rendererID))
+			} else {
+				mustOk(t, g.BlitTexture(textureID, surfaceID, viewport, Rect2D{{0, 0}, {1, 1}}, rendererID))
+			}
+			checkTestQuadrants(t, g, surfaceID, viewport, name)
+			// the bars on either side of the content stay clear
+			for _, p := range []IVec2{viewport[0].Sub(IVec2{1, 1}), viewport[1]} {
+				x, y := math.Clamp(0, p.X(), size.X()-1), math.Clamp(0, p.Y(), size.Y()-1)
+				if got := testPixel(t, g, surfaceID, x, y); got != [4]uint8{} {
+					t.Errorf("%s: bar pixel (%d, %d) = %v, want it untouched", name, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestBlitTextureKeepsNoBatches(t *testing.T) {
+	g, surfaceID := newTestSoftware(t, testAxes, IVec2{8, 8}, false)
+	rendererID, err := g.AddRenderer(Pos2D|HasTex, nil)
+	mustOk(t, err)
+	textureID := addTestQuadrantTexture(t, g)
+	// more blits than there are batch IDs
+	for i := 0; i < 300; i += 1 {
+		mustOk(t, g.BlitTexture(textureID, surfaceID, IRect2D{{0, 0}, {8, 8}}, Rect2D{{0, 0}, {1, 1}}, rendererID))
+	}
+	checkTestQuadrants(t, g, surfaceID, IRect2D{{0, 0}, {8, 8}}, "after repeated blits")
+	if g.IsBatchValid(0) {
+		t.Error("a blit batch was left behind")
+	}
+}
+
+func TestDeleteSurfaceFreesIt(t *testing.T) {
+	g, keptID := newTestSoftware(t, testAxes, IVec2{4, 4}, false)
+	software := g.GraphicsInterface.(*SoftwareGraphics)
+	surfaceID, textureID, err := g.AddDrawSurface(IVec2{4, 4}, 0)
+	mustOk(t, err)
+	mustOk(t, g.DeleteSurface(surfaceID))
+	if err := g.ClearSurface(surfaceID, testRed); !err.IsErr {
+		t.Error("clearing a deleted surface returned no error")
+	}
+	if err := g.DeleteSurface(surfaceID); !err.IsErr {
+		t.Error("deleting a surface twice returned no error")
+	}
+	if software.surfaceImages[surfaceID] != nil || software.textureImages[textureID] != nil {
+		t.Error("deleted surface's image or texture was kept")
+	}
+	mustOk(t, g.ClearSurface(keptID, testRed))
+	next, _, err := g.AddDrawSurface(IVec2{4, 4}, 0)
+	mustOk(t, err)
+	if next == surfaceID {
+		t.Error("a deleted surface ID was reused")
+	}
+}
+
+func TestDrawSpritesImmediate(t *testing.T) {
+	g, surfaceID := newTestSoftware(t, testAxes, IVec2{16, 16}, false)
+	rendererID, err := g.AddRenderer(Pos2D|HasTex|ColFA, nil)
+	mustOk(t, err)
+	textureID := addTestQuadrantTexture(t, g)
+	red, blue := Rect2D{{0, 0}, {0.5, 0.5}}, Rect2D{{0, 0.5}, {0.5, 1}}
+	sprites := []SpriteDraw{
+		{Dest: Rect2D{{-1, -1}, {0, 0}}, TexRegion: red, Color: ColorFA{1, 1, 1, 1}},
+		// drawn later, so it covers the first sprite where they overlap
+		{Dest: Rect2D{{-0.5, -0.5}, {0.5, 0.5}}, TexRegion: blue, Color: ColorFA{1, 1, 1, 1}},
+	}
+	mustOk(t, g.DrawSpritesImmediate(surfaceID, rendererID, textureID, sprites))
+	if got := testPixel(t, g, surfaceID, 1, 14); got != [4]uint8{255, 0, 0, 255} {
+		t.Errorf("first sprite pixel = %v, want red", got)
+	}
+	if got := testPixel(t, g, surfaceID, 6, 9); got != [4]uint8{0, 0, 255, 255} {
+		t.Errorf("overlapping pixel = %v, want the later blue sprite", got)
+	}
+	if got := testPixel(t, g, surfaceID, 14, 1); got != [4]uint8{} {
+		t.Errorf("pixel outside the sprites = %v, want it untouched", got)
+	}
+	if g.IsBatchValid(0) {
+		t.Error("the sprite batch was left behind")
+	}
+}
+
+const benchmarkSpriteCount = 5000
+
+func benchmarkSpriteDest(i int, frame int) Rect2D {
+	min := benchmarkInstanceOffset(i, frame).AsVec2()
+	return Rect2D{min, min.Add(Vec2{0.02, 0.02})}
+}
+
+func BenchmarkDrawSpritesImmediate(b *testing.B) {
+	g, surfaceID := newTestSoftware(b, testAxes, IVec2{128, 128}, false)
+	rendererID, err := g.AddRenderer(Pos2D|HasTex|ColFA, nil)
+	mustOk(b, err)
+	textureID := addTestQuadrantTexture(b, g)
+	software := g.GraphicsInterface.(*SoftwareGraphics)
+	sprites := make([]SpriteDraw, benchmarkSpriteCount)
+	b.ResetTimer()
+	for frame := 0; frame < b.N; frame += 1 {
+		// keep the call log from growing across iterations
+		software.Log = nil
+		for i := range sprites {
+			sprites[i] = SpriteDraw{Dest: benchmarkSpriteDest(i, frame), TexRegion: Rect2D{{0, 0}, {1, 1}}, Color: testRed}
+		}
+		mustOk(b, g.DrawSpritesImmediate(surfaceID, rendererID, textureID, sprites))
+	}
+}
+
+func BenchmarkRetainedSprites(b *testing.B) {
+	g, surfaceID := newTestSoftware(b, testAxes, IVec2{128, 128}, false)
+	rendererID, err := g.AddRenderer(Pos2D|HasTex|ColFA, nil)
+	mustOk(b, err)
+	textureID := addTestQuadrantTexture(b, g)
+	software := g.GraphicsInterface.(*SoftwareGraphics)
+	batchID, err := g.AddDrawBatch(Pos2D|HasTex|ColFA, textureID, benchmarkSpriteCount*4)
+	mustOk(b, err)
+	shapes := make([]BatchShape, benchmarkSpriteCount)
+	for i := range shapes {
+		shapes[i], err = g.AddSprite2D(batchID, benchmarkSpriteDest(i, 0), Rect2D{{0, 0}, {1, 1}}, testRed, false, false, 0)
+		mustOk(b, err)
+	}
+	b.ResetTimer()
+	for frame := 0; frame < b.N; frame += 1 {
+		software.Log = nil
+		for i, shape := range shapes {
+			mustOk(b, g.UpdateSprite2D(shape, benchmarkSpriteDest(i, frame), Rect2D{{0, 0}, {1, 1}}, testRed, false, false, 0))
+		}
+		mustOk(b, g.DrawBatch(batchID, surfaceID, rendererID, true))
+	}
+}
+
+func TestAddTextureRejectsNilAndOversizedTextures(t *testing.T) {
+	s := NewSoftwareGraphics(testAxes)
+	if _, err := s.AddTexture(nil); !err.IsErr {
+		t.Error("AddTexture(nil) returned no error")
+	}
+	_, done := s.AddTextureAsync(nil)
+	if err := <-done; !err.IsErr {
+		t.Error("AddTextureAsync(nil) returned no error")
+	}
+	// the encoded size is only known once decoded, so the async check happens on the worker
+	encoded, err := EncodeImage(make([]byte, 4*4*4), IVec2{4, 4}, ImgPNG)
+	mustOk(t, err)
+	s.Limits.MaxTextureSize = 2
+	if _, err := s.AddTexture(&Texture{Data: encoded, ImgType: ImgPNG}); !err.IsErr {
+		t.Error("AddTexture of an oversized PNG returned no error")
+	}
+	id, done := s.AddTextureAsync(&Texture{Data: encoded, ImgType: ImgPNG})
+	if err := <-done; !err.IsErr {
+		t.Error("AddTextureAsync of an oversized PNG returned no error")
+	}
+	s.uploadPendingTextures()
+	if size := s.textureImages[id].Rect.Size(); size.X > 2 || size.Y > 2 {
+		t.Errorf("oversized texture was swapped in at %v", size)
+	}
+}
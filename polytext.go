@@ -0,0 +1,490 @@
+package polyapp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	math "github.com/gabe-lee/genmath"
+	utils "github.com/gabe-lee/genutils"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Metrics of a single glyph, in texels of the font's texture at a scale of 1
+type GlyphInfo struct {
+	// Area of the glyph in the texture, empty for glyphs that only advance (such as space)
+	Region Rect2D
+	// From the pen position to the top-left of the glyph, +Y down the line
+	Offset Vec2
+	// Distance the pen moves after the glyph
+	Advance float32
+}
+
+// A bitmap font stored in a texture. All metrics are in texels at a scale of 1.
+type FontAtlas struct {
+	TextureID  TextureID
+	Size       IVec2
+	LineHeight float32
+	// Distance from the top of a line to the baseline
+	Base   float32
+	Glyphs map[rune]GlyphInfo
+	// Adjustment to the advance between a pair of consecutive runes, pairs missing from the map adjust by 0
+	Kerning map[[2]rune]float32
+}
+
+// Returns the kerning adjustment between first and second, or 0 if the pair has none
+func (f *FontAtlas) Kern(first rune, second rune) float32 {
+	return f.Kerning[[2]rune{first, second}]
+}
+
+// Loads the glyph metrics and kerning pairs of a BMFont text format (.fnt) file.
+// Only single page fonts are supported, the page's texture must already be added as textureID.
+func LoadBMFont(fnt []byte, textureID TextureID) (*FontAtlas, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] LoadBMFont():")
+	dErr.IsErr = false
+	atlas := &FontAtlas{
+		TextureID: textureID,
+		Glyphs:    map[rune]GlyphInfo{},
+		Kerning:   map[[2]rune]float32{},
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(fnt))
+	for line := 1; scanner.Scan(); line += 1 {
+		tag, values := parseBMFontLine(scanner.Text())
+		num := func(key string) float32 {
+			value, err := strconv.ParseFloat(values[key], 32)
+			if err != nil && !dErr.IsErr {
+				dErr.AddChildError(fmt.Errorf("line %d: %s %s is not a number", line, tag, key))
+			}
+			return float32(value)
+		}
+		switch tag {
+		case "common":
+			atlas.LineHeight = num("lineHeight")
+			atlas.Base = num("base")
+			atlas.Size = IVec2{int32(num("scaleW")), int32(num("scaleH"))}
+			if pages, ok := values["pages"]; ok && pages != "1" {
+				dErr.AddChildError(fmt.Errorf("line %d: font has %s pages, only single page fonts are supported", line, pages))
+			}
+		case "char":
+			x, y := num("x"), num("y")
+			atlas.Glyphs[rune(num("id"))] = GlyphInfo{
+				Region:  Rect2D{{x, y}, {x + num("width"), y + num("height")}},
+				Offset:  Vec2{num("xoffset"), num("yoffset")},
+				Advance: num("xadvance"),
+			}
+		case "kerning":
+			atlas.Kerning[[2]rune{rune(num("first")), rune(num("second"))}] = num("amount")
+		}
+	}
+	dErr.AddChildError(scanner.Err())
+	if atlas.Size.X() == 0 || atlas.Size.Y() == 0 {
+		dErr.AddChildError(fmt.Errorf("font has no common line giving the texture size"))
+	}
+	return atlas, dErr
+}
+
+// Rasterizes runes from a TrueType or OpenType font into a new atlas texture, with glyphs pixelHeight pixels
+// per em. The texture holds raw RGBA8 pixels (white, with the glyph coverage as alpha) ready for AddTexture,
+// whose returned ID must then be stored in the atlas's TextureID.
+//
+// Runes the font has no glyph for are left out of the atlas, so text skips them like any other missing rune.
+// Kerning comes from the font's kern table (GPOS kerning is not read) and is looked up for every pair of
+// runes, which gets slow for sets of thousands of runes.
+func RasterizeFont(ttfData []byte, pixelHeight float32, runes []rune) (*Texture, *FontAtlas, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] RasterizeFont():")
+	dErr.IsErr = false
+	if pixelHeight <= 0 {
+		dErr.AddChildError(fmt.Errorf("pixel height must be greater than 0"))
+		return nil, nil, dErr
+	}
+	parsed, err := opentype.Parse(ttfData)
+	if err != nil {
+		dErr.AddChildError(fmt.Errorf("font data could not be parsed: %w", err))
+		return nil, nil, dErr
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: float64(pixelHeight), DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		dErr.AddChildError(fmt.Errorf("font face could not be created: %w", err))
+		return nil, nil, dErr
+	}
+	defer face.Close()
+	metrics := face.Metrics()
+	atlas := &FontAtlas{
+		LineHeight: fixedToFloat(metrics.Height),
+		Base:       fixedToFloat(metrics.Ascent),
+		Glyphs:     map[rune]GlyphInfo{},
+		Kerning:    map[[2]rune]float32{},
+	}
+	type rasterGlyph struct {
+		r      rune
+		bounds image.Rectangle
+		mask   image.Image
+		maskP  image.Point
+		pos    image.Point
+	}
+	var buf sfnt.Buffer
+	glyphs := make([]rasterGlyph, 0, len(runes))
+	for _, r := range runes {
+		if _, done := atlas.Glyphs[r]; done {
+			continue
+		}
+		// glyph 0 is the font's placeholder for runes it does not have
+		if index, err := parsed.GlyphIndex(&buf, r); err != nil || index == 0 {
+			continue
+		}
+		bounds, mask, maskP, advance, ok := face.Glyph(fixed.Point26_6{}, r)
+		if !ok {
+			continue
+		}
+		atlas.Glyphs[r] = GlyphInfo{
+			Offset:  Vec2{float32(bounds.Min.X), atlas.Base + float32(bounds.Min.Y)},
+			Advance: fixedToFloat(advance),
+		}
+		if !bounds.Empty() {
+			// the face reuses its mask for the next glyph, so keep a copy
+			copied := image.NewAlpha(image.Rectangle{Max: bounds.Size()})
+			draw.Draw(copied, copied.Rect, mask, maskP, draw.Src)
+			glyphs = append(glyphs, rasterGlyph{r: r, bounds: bounds, mask: copied})
+		}
+	}
+	for first := range atlas.Glyphs {
+		for second := range atlas.Glyphs {
+			if kern := face.Kern(first, second); kern != 0 {
+				atlas.Kerning[[2]rune{first, second}] = fixedToFloat(kern)
+			}
+		}
+	}
+	// shelf packing, tallest glyphs first, with a texel of padding so sampling never bleeds between glyphs
+	sort.Slice(glyphs, func(a, b int) bool {
+		return glyphs[a].bounds.Dy() > glyphs[b].bounds.Dy()
+	})
+	area, widest := 0, 0
+	for _, g := range glyphs {
+		area += (g.bounds.Dx() + 1) * (g.bounds.Dy() + 1)
+		widest = math.Max(widest, g.bounds.Dx()+1)
+	}
+	width := 1
+	for width*width < area || width < widest {
+		width *= 2
+	}
+	pen, shelfHeight := image.Point{}, 0
+	for i := range glyphs {
+		if pen.X+glyphs[i].bounds.Dx()+1 > width {
+			pen = image.Point{0, pen.Y + shelfHeight}
+			shelfHeight = 0
+		}
+		glyphs[i].pos = pen
+		pen.X += glyphs[i].bounds.Dx() + 1
+		shelfHeight = math.Max(shelfHeight, glyphs[i].bounds.Dy()+1)
+	}
+	height := math.Max(1, pen.Y+shelfHeight)
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for _, g := range glyphs {
+		dst := image.Rectangle{g.pos, g.pos.Add(g.bounds.Size())}
+		draw.DrawMask(img, dst, image.White, image.Point{}, g.mask, image.Point{}, draw.Src)
+		info := atlas.Glyphs[g.r]
+		info.Region = Rect2D{{float32(dst.Min.X), float32(dst.Min.Y)}, {float32(dst.Max.X), float32(dst.Max.Y)}}
+		atlas.Glyphs[g.r] = info
+	}
+	atlas.Size = IVec2{int32(width), int32(height)}
+	texture := &Texture{
+		Data:    img.Pix,
+		ImgType: ImgUnknown,
+		Size:    atlas.Size,
+	}
+	return texture, atlas, dErr
+}
+
+func fixedToFloat(value fixed.Int26_6) float32 {
+	return float32(value) / 64
+}
+
+// Splits a BMFont line into its tag and key=value pairs, values may be quoted
+func parseBMFontLine(line string) (string, map[string]string) {
+	line = strings.TrimSpace(line)
+	tag, rest, _ := strings.Cut(line, " ")
+	values := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(after, "\"") {
+			value, rest, _ = strings.Cut(after[1:], "\"")
+		} else {
+			value, rest, _ = strings.Cut(after, " ")
+		}
+		values[key] = value
+	}
+	return tag, values
+}
+
+// Lays out text from the top-left of its first line, with +X right and +Y down the lines, calling emit
+// (if not nil) with every glyph and the pen position it is drawn at. Lines break at '\n' and, when maxWidth
+// is greater than 0, before any word that would cross maxWidth. Runes missing from the atlas are skipped.
+// Returns the size of the laid out text.
+func layoutText(atlas *FontAtlas, text string, scale float32, maxWidth float32, emit func(glyph GlyphInfo, pen Vec2)) Vec2 {
+	lineHeight := atlas.LineHeight * scale
+	pen := ZeroVec2
+	// end of the last glyph that was not a space, so trailing spaces don't count towards the width
+	lineEnd, width := float32(0), float32(0)
+	prev := rune(-1)
+	for i := 0; i < len(text); {
+		r, n := utf8.DecodeRuneInString(text[i:])
+		if r == '\n' {
+			width = math.Max(width, lineEnd)
+			pen, lineEnd, prev = Vec2{0, pen.Y() + lineHeight}, 0, -1
+			i += n
+			continue
+		}
+		if maxWidth > 0 && r != ' ' && (prev == -1 || prev == ' ') && pen.X() > 0 {
+			if pen.X()+measureWord(atlas, text[i:], scale, prev) > maxWidth {
+				width = math.Max(width, lineEnd)
+				pen, lineEnd, prev = Vec2{0, pen.Y() + lineHeight}, 0, -1
+			}
+		}
+		glyph, ok := atlas.Glyphs[r]
+		if !ok {
+			i += n
+			continue
+		}
+		if prev != -1 {
+			pen[0] += atlas.Kern(prev, r) * scale
+		}
+		if emit != nil {
+			emit(glyph, pen)
+		}
+		pen[0] += glyph.Advance * scale
+		if r != ' ' {
+			lineEnd = pen.X()
+		}
+		prev = r
+		i += n
+	}
+	width = math.Max(width, lineEnd)
+	return Vec2{width, pen.Y() + lineHeight}
+}
+
+// Returns the size text would be laid out at by AddText2D, without allocating
+func MeasureText(atlas *FontAtlas, text string, scale float32) Vec2 {
+	return layoutText(atlas, text, scale, 0, nil)
+}
+
+// Returns the size text would be laid out at by AddTextWrapped2D, without allocating
+func MeasureTextWrapped(atlas *FontAtlas, text string, maxWidth float32, scale float32) Vec2 {
+	return layoutText(atlas, text, scale, maxWidth, nil)
+}
+
+// Returns the advance of the word at the start of text, including the kerning after prev
+func measureWord(atlas *FontAtlas, text string, scale float32, prev rune) float32 {
+	width := float32(0)
+	for i := 0; i < len(text); {
+		r, n := utf8.DecodeRuneInString(text[i:])
+		if r == ' ' || r == '\n' {
+			break
+		}
+		if glyph, ok := atlas.Glyphs[r]; ok {
+			if prev != -1 {
+				width += atlas.Kern(prev, r) * scale
+			}
+			width += glyph.Advance * scale
+			prev = r
+		}
+		i += n
+	}
+	return width
+}
+
+// Creates a single shape holding a quad for every visible glyph of text, with origin at the top-left of the
+// first line, lines running down the screen (following XRightYUpZAway()) and glyphs scaled from texels by scale.
+// Consecutive glyphs are adjusted by the atlas kerning. The batch should use the atlas texture.
+func (g GraphicsProvider) AddText2D(batchID BatchID, atlas *FontAtlas, text string, origin Vec2, scale float32, color ColorFA) (BatchShape, DeepError) {
+	return g.addText("AddText2D", batchID, atlas, text, origin, 0, scale, color)
+}
+
+// Same as AddText2D, but words that would cross maxWidth are moved to the next line
+func (g GraphicsProvider) AddTextWrapped2D(batchID BatchID, atlas *FontAtlas, text string, origin Vec2, maxWidth float32, scale float32, color ColorFA) (BatchShape, DeepError) {
+	return g.addText("AddTextWrapped2D", batchID, atlas, text, origin, maxWidth, scale, color)
+}
+
+// Rounds the top-left corner of every glyph to a whole pixel of SurfaceID as seen through RendererID's camera,
+// so static text drawn with nearest sampling keeps every texel on a pixel and linear sampling does not blur.
+// Snapping is in surface pixels, which are physical pixels for window surfaces whatever the display's scaling.
+// Text that moves or scales smoothly should not be snapped, as its glyphs would jitter between pixels.
+// Only meaningful for NoCam and Cam2D renderers.
+type PixelSnap struct {
+	RendererID RendererID
+	SurfaceID  SurfaceID
+}
+
+// Same as AddText2D, with glyphs snapped to whole pixels as described by PixelSnap
+func (g GraphicsProvider) AddTextSnapped2D(batchID BatchID, atlas *FontAtlas, text string, origin Vec2, scale float32, color ColorFA, snap PixelSnap) (BatchShape, DeepError) {
+	return g.addTextSnapped("AddTextSnapped2D", batchID, atlas, text, origin, 0, scale, color, &snap, g.textDown())
+}
+
+// Rewrites a text shape, the new text must have the same number of visible glyphs as the shape was created with
+func (g GraphicsProvider) UpdateText2D(shape BatchShape, atlas *FontAtlas, text string, origin Vec2, scale float32, color ColorFA) DeepError {
+	return g.updateText("UpdateText2D", shape, atlas, text, origin, 0, scale, color)
+}
+
+func (g GraphicsProvider) UpdateTextWrapped2D(shape BatchShape, atlas *FontAtlas, text string, origin Vec2, maxWidth float32, scale float32, color ColorFA) DeepError {
+	return g.updateText("UpdateTextWrapped2D", shape, atlas, text, origin, maxWidth, scale, color)
+}
+
+func (g GraphicsProvider) UpdateTextSnapped2D(shape BatchShape, atlas *FontAtlas, text string, origin Vec2, scale float32, color ColorFA, snap PixelSnap) DeepError {
+	return g.updateTextSnapped("UpdateTextSnapped2D", shape, atlas, text, origin, 0, scale, color, &snap, g.textDown())
+}
+
+// Returns +1 when lines of text run towards +Y in the world, so that they run down the surface under
+// XRightYUpZAway(), and -1 when they run towards -Y
+func (g GraphicsProvider) textDown() float32 {
+	return -math.Sign(g.XRightYUpZAway().Y())
+}
+
+func (g GraphicsProvider) addText(method string, batchID BatchID, atlas *FontAtlas, text string, origin Vec2, maxWidth float32, scale float32, color ColorFA) (BatchShape, DeepError) {
+	return g.addTextSnapped(method, batchID, atlas, text, origin, maxWidth, scale, color, nil, g.textDown())
+}
+
+func (g GraphicsProvider) addTextSnapped(method string, batchID BatchID, atlas *FontAtlas, text string, origin Vec2, maxWidth float32, scale float32, color ColorFA, snap *PixelSnap, down float32) (BatchShape, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] " + method + "():")
+	dErr.IsErr = false
+	glyphs := uint32(0)
+	layoutText(atlas, text, scale, maxWidth, func(glyph GlyphInfo, pen Vec2) {
+		if glyph.Region.W() > 0 && glyph.Region.H() > 0 {
+			glyphs += 1
+		}
+	})
+	idx := make([]uint32, 0, glyphs*6)
+	for v := uint32(0); v < glyphs*4; v += 4 {
+		idx = append(idx, v, v+1, v+2, v+2, v+3, v)
+	}
+	bSlice, err := g.AllocateShapeInBatch(batchID, ShapePrototype{
+		VertCount:  glyphs * 4,
+		IndexCount: glyphs * 6,
+		Indexes:    idx,
+	})
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return bSlice, dErr
+	}
+	dErr.AddChildDeepError(g.updateTextSnapped(method, bSlice, atlas, text, origin, maxWidth, scale, color, snap, down))
+	return bSlice, dErr
+}
+
+func (g GraphicsProvider) updateText(method string, shape BatchShape, atlas *FontAtlas, text string, origin Vec2, maxWidth float32, scale float32, color ColorFA) DeepError {
+	return g.updateTextSnapped(method, shape, atlas, text, origin, maxWidth, scale, color, nil, g.textDown())
+}
+
+// Same as updateText, moving each glyph so its top-left lands on a whole pixel when snap is not nil.
+// Lines run towards +Y in the world when down is +1 and towards -Y when it is -1 (see textDown).
+func (g GraphicsProvider) updateTextSnapped(method string, shape BatchShape, atlas *FontAtlas, text string, origin Vec2, maxWidth float32, scale float32, color ColorFA, snap *PixelSnap, down float32) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] " + method + "():")
+	dErr.IsErr = false
+	texSize := Vec2{float32(atlas.Size.X()), float32(atlas.Size.Y())}
+	vertices := make([]Vertex, 0, shape.VertexCount)
+	v := Vertex{
+		Norm:  Vec3{0, 0, -g.XRightYUpZAway()[2]},
+		Color: color,
+	}
+	layoutText(atlas, text, scale, maxWidth, func(glyph GlyphInfo, pen Vec2) {
+		if glyph.Region.W() <= 0 || glyph.Region.H() <= 0 {
+			return
+		}
+		topLeft := pen.Add(glyph.Offset.Scale(scale))
+		size := Vec2{glyph.Region.W(), glyph.Region.H()}.Scale(scale)
+		top, bottom := origin.Y()+down*topLeft.Y(), origin.Y()+down*(topLeft.Y()+size.Y())
+		rect := Rect2D{{origin.X() + topLeft.X(), math.Min(top, bottom)}, {origin.X() + topLeft.X() + size.X(), math.Max(top, bottom)}}
+		if snap != nil && !dErr.IsErr {
+			corner := Vec3{origin.X() + topLeft.X(), top, 0}
+			screen, err := g.WorldToScreen(snap.RendererID, snap.SurfaceID, corner)
+			dErr.AddChildDeepError(err)
+			screen = Vec2{math.Round(screen.X()), math.Round(screen.Y())}
+			snapped, err := g.ScreenToWorld(snap.RendererID, snap.SurfaceID, screen, 0)
+			dErr.AddChildDeepError(err)
+			if !dErr.IsErr {
+				rect = rect.Translate(snapped.Sub(corner).AsVec2())
+			}
+		}
+		uvTop, uvBottom := glyph.Region[0].Y()/texSize.Y(), glyph.Region[1].Y()/texSize.Y()
+		if down < 0 {
+			uvTop, uvBottom = uvBottom, uvTop
+		}
+		uvRect := Rect2D{{glyph.Region[0].X() / texSize.X(), uvTop}, {glyph.Region[1].X() / texSize.X(), uvBottom}}
+		quad, uvQuad := rect.Quad(), uvRect.Quad()
+		for i := 0; i < 4; i += 1 {
+			v.Pos = quad[i].AsVec3()
+			v.UV = uvQuad[i]
+			vertices = append(vertices, v)
+		}
+	})
+	if uint32(len(vertices)) != shape.VertexCount || shape.IndexCount != shape.VertexCount/4*6 {
+		return utils.NewDeepError("[PolyApp] " + method + "(): batch shape provided does not have required dimensions for the visible glyphs of the text")
+	}
+	if dErr.IsErr {
+		return dErr
+	}
+	if len(vertices) > 0 {
+		dErr.AddChildDeepError(g.UpdateVerticesInShape(shape, 0, vertices))
+	}
+	return dErr
+}
+
+// Draws text once into a new draw surface sized to fit it (see MeasureText), for static labels that can then be
+// drawn as a single quad using the returned texture instead of one quad per glyph every frame. The surface
+// starts fully transparent and each texel covers one unit of text at the given scale.
+//
+// The text is drawn with a renderer and batch created for the bake and deleted afterwards, so the bake leaves
+// nothing behind but the surface, which can be freed with DeleteSurface once the texture is no longer drawn.
+// On failure nothing is left behind and the returned TextureID is 0.
+func (g GraphicsProvider) BakeTextToTexture(atlas *FontAtlas, text string, scale float32, color ColorFA) (TextureID, IVec2, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] BakeTextToTexture():")
+	dErr.IsErr = false
+	measured := MeasureText(atlas, text, scale)
+	size := IVec2{int32(math.Ciel(measured.X())), int32(math.Ciel(measured.Y()))}
+	if size.X() <= 0 || size.Y() <= 0 {
+		dErr.AddChildError(fmt.Errorf("text %q has no size to bake", text))
+		return 0, size, dErr
+	}
+	rendererID, err := g.AddRenderer(Pos2D|HasTex|ColFA|Cam2D, nil)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		return 0, size, dErr
+	}
+	batchID, err := g.AddDrawBatch(Pos2D|HasTex|ColFA, atlas.TextureID, uint32(len(text))*4)
+	if err.IsErr {
+		dErr.AddChildDeepError(err)
+		dErr.AddChildDeepError(g.DeleteRenderer(rendererID))
+		return 0, size, dErr
+	}
+	surfaceID, textureID, err := g.AddDrawSurface(size, 0)
+	dErr.AddChildDeepError(err)
+	if !err.IsErr {
+		// YDownOriginTopLeft shows +Y down the surface whatever XRightYUpZAway() is, so with the camera at the
+		// origin the lines run towards +Y from the top-left corner
+		dErr.AddChildDeepError(g.SetRendererCoordinateConvention(rendererID, YDownOriginTopLeft))
+		dErr.AddChildDeepError(g.SetCamera2D(rendererID, ZeroVec2, 1, 0))
+		_, err = g.addTextSnapped("AddText2D", batchID, atlas, text, ZeroVec2, 0, scale, color, nil, 1)
+		dErr.AddChildDeepError(err)
+		dErr.AddChildDeepError(g.ClearSurface(surfaceID, ColorFA{}))
+		if !dErr.IsErr {
+			dErr.AddChildDeepError(g.DrawBatch(batchID, surfaceID, rendererID, true))
+		}
+		if dErr.IsErr {
+			dErr.AddChildDeepError(g.DeleteSurface(surfaceID))
+			textureID = 0
+		}
+	}
+	dErr.AddChildDeepError(g.DeleteBatch(batchID))
+	dErr.AddChildDeepError(g.DeleteRenderer(rendererID))
+	return textureID, size, dErr
+}
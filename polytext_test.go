@@ -0,0 +1,72 @@
+package polyapp
+
+import (
+	"testing"
+)
+
+func TestBakeTextToTextureUpright(t *testing.T) {
+	for _, axes := range []Vec3{{1, 1, 1}, {1, -1, 1}} {
+		g, surfaceID := newTestSoftware(t, axes, IVec2{4, 8}, false)
+		// a glyph with a red top half and a blue bottom half
+		fontTexture, err := g.AddTexture(&Texture{Data: []byte{255, 0, 0, 255, 0, 0, 255, 255}, Size: IVec2{1, 2}})
+		mustOk(t, err)
+		font := &FontAtlas{
+			TextureID:  fontTexture,
+			Size:       IVec2{1, 2},
+			LineHeight: 2,
+			Base:       2,
+			Glyphs:     map[rune]GlyphInfo{'A': {Region: Rect2D{{0, 0}, {1, 2}}, Advance: 1}},
+		}
+		textureID, size, err := g.BakeTextToTexture(font, "A", 4, ColorFA{1, 1, 1, 1})
+		mustOk(t, err)
+		if size != (IVec2{4, 8}) {
+			t.Fatalf("axes %v: baked size %v, want {4 8}", axes, size)
+		}
+		if g.IsBatchValid(0) {
+			t.Errorf("axes %v: the bake left its batch behind", axes)
+		}
+		if _, err := g.GetCamera(0); !err.IsErr {
+			t.Errorf("axes %v: the bake left its renderer behind", axes)
+		}
+
+		blitRenderer, err := g.AddRenderer(Pos2D|HasTex, nil)
+		mustOk(t, err)
+		mustOk(t, g.DrawFullscreenTexture(textureID, surfaceID, blitRenderer))
+		for _, want := range []struct {
+			x, y  int32
+			pixel [4]uint8
+		}{
+			{0, 0, [4]uint8{255, 0, 0, 255}},
+			{3, 3, [4]uint8{255, 0, 0, 255}},
+			{0, 4, [4]uint8{0, 0, 255, 255}},
+			{3, 7, [4]uint8{0, 0, 255, 255}},
+		} {
+			if got := testPixel(t, g, surfaceID, want.x, want.y); got != want.pixel {
+				t.Errorf("axes %v: pixel (%d, %d) = %v, want %v", axes, want.x, want.y, got, want.pixel)
+			}
+		}
+	}
+}
+
+func TestBakeTextToTextureFailureLeavesNothing(t *testing.T) {
+	g, _ := newTestSoftware(t, testAxes, IVec2{4, 4}, false)
+	font := &FontAtlas{
+		LineHeight: 2,
+		Base:       2,
+		Glyphs:     map[rune]GlyphInfo{'A': {Region: Rect2D{{0, 0}, {1, 2}}, Advance: 1}},
+	}
+	// far larger than any surface can be
+	textureID, _, err := g.BakeTextToTexture(font, "A", 1e6, ColorFA{1, 1, 1, 1})
+	if !err.IsErr {
+		t.Fatal("baking text too large for a surface returned no error")
+	}
+	if textureID != 0 {
+		t.Errorf("failed bake returned texture %d, want 0", textureID)
+	}
+	if g.IsBatchValid(0) {
+		t.Error("failed bake left its batch behind")
+	}
+	if _, err := g.GetCamera(0); !err.IsErr {
+		t.Error("failed bake left its renderer behind")
+	}
+}
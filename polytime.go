@@ -0,0 +1,113 @@
+package polyapp
+
+import (
+	"fmt"
+	gomath "math"
+	"time"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+type TimeInterface interface {
+	Now() time.Time
+	// Seconds between the two most recent calls to Tick()
+	DeltaSeconds() float32
+	// Seconds between the first and most recent calls to Tick()
+	TotalSeconds() float32
+	// Number of times Tick() has been called
+	FrameCount() uint64
+	// Marks the start of a new frame, the app calls this once per loop
+	Tick()
+}
+
+var _ TimeInterface = (*TimeProvider)(nil)
+
+type TimeProvider struct {
+	TimeInterface
+}
+
+var _ TimeInterface = (*StdTime)(nil)
+
+// A TimeInterface backed by the standard library's monotonic clock,
+// for platforms without a better timer
+type StdTime struct {
+	start  time.Time
+	last   time.Time
+	delta  float32
+	frames uint64
+}
+
+func NewStdTime() *StdTime {
+	return &StdTime{}
+}
+
+func (t *StdTime) Now() time.Time {
+	return time.Now()
+}
+
+func (t *StdTime) DeltaSeconds() float32 {
+	return t.delta
+}
+
+func (t *StdTime) TotalSeconds() float32 {
+	if t.frames == 0 {
+		return 0
+	}
+	return float32(t.last.Sub(t.start).Seconds())
+}
+
+func (t *StdTime) FrameCount() uint64 {
+	return t.frames
+}
+
+func (t *StdTime) Tick() {
+	now := time.Now()
+	if t.frames == 0 {
+		t.start = now
+	} else {
+		t.delta = float32(now.Sub(t.last).Seconds())
+	}
+	t.last = now
+	t.frames += 1
+}
+
+// The most update steps RunFixedStep makes in a single frame. When a frame takes longer than
+// MaxFixedSteps*fixedDt the remaining time is dropped, so the simulation slows down instead of
+// falling further behind every frame.
+const MaxFixedSteps = 8
+
+// Runs the app loop until app.Quit() is called. Each frame ticks app.Time (a StdTime is used if
+// it has none), calls update with fixedDt once for every fixedDt of real time accumulated, then
+// calls render with alpha, how far (0 to 1) the leftover time is into the next step, for
+// interpolating between the last two updated states. The cursor from EnableGamepadCursor is updated
+// once per frame, before the update steps. Returns a DeepError without running if fixedDt
+// is not a positive, finite number of seconds.
+func RunFixedStep(app *App, fixedDt float32, update func(dt float32), render func(alpha float32)) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] RunFixedStep():")
+	dErr.IsErr = false
+	if !(fixedDt > 0) || gomath.IsInf(float64(fixedDt), 1) {
+		dErr.AddChildError(fmt.Errorf("fixedDt of %v is not a positive, finite number of seconds", fixedDt))
+		return dErr
+	}
+	if app.Time.TimeInterface == nil {
+		app.Time.TimeInterface = NewStdTime()
+	}
+	app.quit = false
+	accumulator := float32(0)
+	for !app.quit {
+		app.Time.Tick()
+		app.UpdateGamepadCursor(app.Time.DeltaSeconds())
+		accumulator += app.Time.DeltaSeconds()
+		steps := 0
+		for accumulator >= fixedDt && steps < MaxFixedSteps {
+			update(fixedDt)
+			accumulator -= fixedDt
+			steps += 1
+		}
+		if steps == MaxFixedSteps && accumulator >= fixedDt {
+			accumulator = 0
+		}
+		render(accumulator / fixedDt)
+	}
+	return dErr
+}
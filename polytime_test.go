@@ -0,0 +1,63 @@
+package polyapp
+
+import (
+	gomath "math"
+	"testing"
+	"time"
+)
+
+// A TimeInterface whose frames each last the next of deltas
+type fakeTime struct {
+	deltas []float32
+	frames uint64
+	delta  float32
+}
+
+func (f *fakeTime) Now() time.Time        { return time.Time{} }
+func (f *fakeTime) DeltaSeconds() float32 { return f.delta }
+func (f *fakeTime) TotalSeconds() float32 { return 0 }
+func (f *fakeTime) FrameCount() uint64    { return f.frames }
+func (f *fakeTime) Tick() {
+	f.delta = f.deltas[f.frames]
+	f.frames += 1
+}
+
+func TestRunFixedStep(t *testing.T) {
+	deltas := []float32{0.25, 0.1, 10, 0.05}
+	app := &App{Time: TimeProvider{&fakeTime{deltas: deltas}}}
+	steps := make([]int, len(deltas))
+	alphas := make([]float32, 0, len(deltas))
+	frame := 0
+	err := RunFixedStep(app, 0.1, func(dt float32) {
+		if dt != 0.1 {
+			t.Errorf("update called with dt %v, want 0.1", dt)
+		}
+		steps[frame] += 1
+	}, func(alpha float32) {
+		alphas = append(alphas, alpha)
+		frame += 1
+		if frame == len(deltas) {
+			app.Quit()
+		}
+	})
+	mustOk(t, err)
+	// the 10 second frame is capped at MaxFixedSteps and its leftover time dropped
+	wantSteps := []int{2, 1, MaxFixedSteps, 0}
+	wantAlphas := []float32{0.5, 0.5, 0, 0.5}
+	for i := range deltas {
+		if steps[i] != wantSteps[i] || !near(alphas[i], wantAlphas[i]) {
+			t.Errorf("frame %d: %d steps and alpha %v, want %d and %v", i, steps[i], alphas[i], wantSteps[i], wantAlphas[i])
+		}
+	}
+}
+
+func TestRunFixedStepRejectsBadStep(t *testing.T) {
+	for _, fixedDt := range []float32{0, -0.1, float32(gomath.NaN()), float32(gomath.Inf(1))} {
+		app := &App{Time: TimeProvider{&fakeTime{deltas: []float32{0.1}}}}
+		called := false
+		err := RunFixedStep(app, fixedDt, func(float32) { called = true }, func(float32) { called = true })
+		if !err.IsErr || called {
+			t.Errorf("fixedDt %v: error %v, callbacks called %v, want an error before running", fixedDt, err.IsErr, called)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package polyapp
+
+import (
+	"fmt"
+	gomath "math"
+
+	utils "github.com/gabe-lee/genutils"
+)
+
+// Maps the linear progress of a tween (0 at the start, 1 at the end) to the eased progress.
+// Results may leave [0, 1] for easings that overshoot, such as the elastic ones.
+type EaseFunc func(t float32) float32
+
+func EaseLinear(t float32) float32 {
+	return t
+}
+
+func EaseInQuad(t float32) float32 {
+	return t * t
+}
+
+func EaseOutQuad(t float32) float32 {
+	return 1 - (1-t)*(1-t)
+}
+
+func EaseInOutQuad(t float32) float32 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return 1 - 2*(1-t)*(1-t)
+}
+
+func EaseInCubic(t float32) float32 {
+	return t * t * t
+}
+
+func EaseOutCubic(t float32) float32 {
+	return 1 - (1-t)*(1-t)*(1-t)
+}
+
+func EaseInOutCubic(t float32) float32 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - 4*(1-t)*(1-t)*(1-t)
+}
+
+// Springs past the end and settles back, like a plucked elastic band
+func EaseOutElastic(t float32) float32 {
+	if t <= 0 || t >= 1 {
+		return t
+	}
+	return float32(gomath.Pow(2, float64(-10*t))*gomath.Sin((float64(t)*10-0.75)*2*gomath.Pi/3)) + 1
+}
+
+// Winds up with growing swings before reaching the end, the reverse of EaseOutElastic
+func EaseInElastic(t float32) float32 {
+	return 1 - EaseOutElastic(1-t)
+}
+
+type TweenProperty uint8
+
+const (
+	// Every vertex color of the shape, from and to are ColorFA
+	TweenColor TweenProperty = iota
+	// The shape's offset from where its vertices were written, applied with SetShapeTransform (replacing
+	// any transform already set), from and to are Vec2 or Vec3
+	TweenPosition
+)
+
+type tween struct {
+	shape    BatchShape
+	property TweenProperty
+	from     [4]float32
+	to       [4]float32
+	duration float32
+	elapsed  float32
+	ease     EaseFunc
+}
+
+// Animates shape properties over time. Register tweens with Tween, then call Update once per frame
+// with the frame time (such as TimeProvider.DeltaSeconds()) to apply every active tween.
+type Tweener struct {
+	graphics GraphicsProvider
+	tweens   []*tween
+}
+
+func (g GraphicsProvider) NewTweener() *Tweener {
+	return &Tweener{graphics: g}
+}
+
+// Starts animating property of target from from to to over duration seconds, replacing any tween already
+// running on the same shape and property. A nil ease is EaseLinear. The from value is applied on the next Update.
+func (tw *Tweener) Tween(target BatchShape, property TweenProperty, from any, to any, duration float32, ease EaseFunc) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] Tweener.Tween():")
+	dErr.IsErr = false
+	fromValue, err := tweenValue(property, from)
+	dErr.AddChildDeepError(err)
+	toValue, err := tweenValue(property, to)
+	dErr.AddChildDeepError(err)
+	if dErr.IsErr {
+		return dErr
+	}
+	if ease == nil {
+		ease = EaseLinear
+	}
+	tw.Cancel(target, property)
+	tw.tweens = append(tw.tweens, &tween{shape: target, property: property, from: fromValue, to: toValue, duration: duration, ease: ease})
+	return dErr
+}
+
+func tweenValue(property TweenProperty, value any) ([4]float32, DeepError) {
+	dErr := utils.NewDeepError("[PolyApp] tweenValue():")
+	dErr.IsErr = false
+	switch v := value.(type) {
+	case ColorFA:
+		if property == TweenColor {
+			return v, dErr
+		}
+	case Vec2:
+		if property == TweenPosition {
+			return [4]float32{v.X(), v.Y()}, dErr
+		}
+	case Vec3:
+		if property == TweenPosition {
+			return [4]float32{v.X(), v.Y(), v.Z()}, dErr
+		}
+	}
+	dErr.AddChildError(fmt.Errorf("value of type %T cannot be used for tween property %d", value, property))
+	return [4]float32{}, dErr
+}
+
+// Stops the tween running on the shape and property, leaving the shape as it was last updated
+func (tw *Tweener) Cancel(target BatchShape, property TweenProperty) {
+	for i, t := range tw.tweens {
+		if t.shape == target && t.property == property {
+			tw.tweens = append(tw.tweens[:i], tw.tweens[i+1:]...)
+			return
+		}
+	}
+}
+
+// Returns the number of tweens still running
+func (tw *Tweener) Active() int {
+	return len(tw.tweens)
+}
+
+// Advances every tween by dt seconds and applies its value to its shape. Tweens that reach their
+// end apply the to value exactly and are removed, as are tweens whose shape can no longer be updated.
+func (tw *Tweener) Update(dt float32) DeepError {
+	dErr := utils.NewDeepError("[PolyApp] Tweener.Update():")
+	dErr.IsErr = false
+	running := tw.tweens[:0]
+	for _, t := range tw.tweens {
+		t.elapsed += dt
+		progress := float32(1)
+		if t.duration > 0 && t.elapsed < t.duration {
+			progress = t.ease(t.elapsed / t.duration)
+		}
+		var value [4]float32
+		for i := range value {
+			value[i] = t.from[i] + (t.to[i]-t.from[i])*progress
+		}
+		err := tw.apply(t, value)
+		dErr.AddChildDeepError(err)
+		if !err.IsErr && t.elapsed < t.duration {
+			running = append(running, t)
+		}
+	}
+	for i := len(running); i < len(tw.tweens); i += 1 {
+		tw.tweens[i] = nil
+	}
+	tw.tweens = running
+	return dErr
+}
+
+func (tw *Tweener) apply(t *tween, value [4]float32) DeepError {
+	g := tw.graphics
+	if t.property == TweenPosition {
+		return g.SetShapeTransform(t.shape, Translate3D(Vec3{value[0], value[1], value[2]}))
+	}
+	dErr := utils.NewDeepError("[PolyApp] Tweener.apply():")
+	dErr.IsErr = false
+	vertices := make([]Vertex, t.shape.VertexCount)
+	for i := range vertices {
+		vert, err := g.GetVertexInShape(t.shape, uint32(i))
+		if err.IsErr {
+			dErr.AddChildDeepError(err)
+			return dErr
+		}
+		vert.Color = value
+		vertices[i] = vert
+	}
+	dErr.AddChildDeepError(g.UpdateVerticesInShape(t.shape, 0, vertices))
+	return dErr
+}
@@ -19,6 +19,14 @@ type WindowInterface interface {
 	SetMaximizeCallback(windowID uint8, op func(maximized bool)) error
 	SetPosCallback(windowID uint8, op func(pos IVec2)) error
 	SetSizeCallback(windowID uint8, op func(size IVec2)) error
+	// Sets op to be called whenever the window's contents are lost and need redrawing, such as after
+	// being uncovered or resized, so apps that only draw on change can wait for input or a refresh.
+	// When a resize causes the refresh, the size callback is called first, so op sees the new size.
+	SetRefreshCallback(windowID uint8, op func()) error
+	// Sets op to be called with true when the cursor moves into the window's content area and false when it
+	// leaves. A cursor captured by the window (hidden and locked to it) counts as inside: entered is reported
+	// when it is captured if it was outside, and leave is not reported until it is released.
+	SetCursorEnterCallback(windowID uint8, op func(entered bool)) error
 }
 
 var _ WindowInterface = (*WindowProvider)(nil)